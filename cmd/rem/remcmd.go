@@ -0,0 +1,272 @@
+package remcmd
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/evantbyrne/rem"
+)
+
+type Config struct {
+	DB            *sql.DB
+	Dir           string
+	MigrateConfig rem.MigrateConfig
+	Migrations    []rem.Migration
+	Models        []rem.MigrationModel
+	Output        io.Writer
+	Package       string
+}
+
+const usage = `Usage: <command> [flags] [arguments]
+
+Commands:
+  up           Run all pending migrations.
+  down [n]     Roll back the last n migrations. Defaults to 1.
+  status       List migrations and whether they have been applied.
+  to <name>    Migrate up or down to the named migration.
+  redo         Roll back and re-run the last applied migration.
+  new <name>   Create a migration file. Generated from models when configured.
+
+Flags for up, down, to, and redo:
+  -dry-run              Print the SQL script instead of running migrations.
+  -lock-timeout <d>     Wait at most this long for the migration lock, such as 30s.
+`
+
+func Main(config Config) {
+	if err := Run(config, os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func Run(config Config, args []string) error {
+	if config.Dir == "" {
+		config.Dir = "migrations"
+	}
+	if config.Output == nil {
+		config.Output = os.Stdout
+	}
+	if config.Package == "" {
+		config.Package = filepath.Base(config.Dir)
+	}
+	if len(args) == 0 {
+		fmt.Fprint(config.Output, usage)
+		return errors.New("rem: missing command")
+	}
+
+	// Flags follow the command and override the config.
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.BoolVar(&config.MigrateConfig.DryRun, "dry-run", config.MigrateConfig.DryRun, "")
+	flags.DurationVar(&config.MigrateConfig.LockTimeout, "lock-timeout", config.MigrateConfig.LockTimeout, "")
+	if err := flags.Parse(args[1:]); err != nil {
+		return fmt.Errorf("rem: %w", err)
+	}
+	args = append([]string{args[0]}, flags.Args()...)
+
+	switch args[0] {
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			var err error
+			if n, err = strconv.Atoi(args[1]); err != nil || n < 1 {
+				return fmt.Errorf("rem: invalid number of migrations '%s'", args[1])
+			}
+		}
+		return down(config, n)
+
+	case "new":
+		if len(args) < 2 {
+			return errors.New("rem: missing migration name")
+		}
+		return create(config, args[1])
+
+	case "redo":
+		return redo(config)
+
+	case "status":
+		return status(config)
+
+	case "to":
+		if len(args) < 2 {
+			return errors.New("rem: missing migration name")
+		}
+		return to(config, args[1])
+
+	case "up":
+		logs, err := rem.MigrateUp(config.DB, config.Migrations, config.MigrateConfig)
+		printLogs(config, logs)
+		return err
+	}
+
+	fmt.Fprint(config.Output, usage)
+	return fmt.Errorf("rem: unknown command '%s'", args[0])
+}
+
+func create(config Config, name string) error {
+	identifier := migrationIdentifier(name)
+	if identifier == "" {
+		return fmt.Errorf("rem: invalid migration name '%s'", name)
+	}
+	number := len(config.Migrations) + 1
+	typeName := fmt.Sprintf("Migration%04d%s", number, identifier)
+	path := filepath.Join(config.Dir, fmt.Sprintf("%04d_%s.go", number, migrationFileName(name)))
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("rem: migration file '%s' already exists", path)
+	}
+
+	source := fmt.Sprintf(`package %s
+
+import (
+	"database/sql"
+)
+
+type %s struct{}
+
+func (m %s) Up(db *sql.DB) error {
+	return nil
+}
+
+func (m %s) Down(db *sql.DB) error {
+	return nil
+}
+`, config.Package, typeName, typeName, typeName)
+	if len(config.Models) > 0 {
+		generated, err := rem.GenerateMigration(config.DB, rem.MigrationGenerateConfig{Name: typeName, Package: config.Package}, config.Models...)
+		if err == nil {
+			source = generated
+		} else if errors.Is(err, rem.ErrNoSchemaChanges) {
+			fmt.Fprintln(config.Output, "No schema changes detected. Creating an empty migration.")
+		} else {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(config.Dir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(config.Output, "Created %s. Add %s{} to the migrations list.\n", path, typeName)
+	return nil
+}
+
+func down(config Config, n int) error {
+	logs, err := rem.MigrateDownN(config.DB, config.Migrations, n, config.MigrateConfig)
+	printLogs(config, logs)
+	return err
+}
+
+func migrationFileName(name string) string {
+	var out strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) && i > 0 {
+			out.WriteRune('_')
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			out.WriteRune(unicode.ToLower(r))
+		} else {
+			out.WriteRune('_')
+		}
+	}
+	return strings.Trim(out.String(), "_")
+}
+
+func migrationIdentifier(name string) string {
+	var out strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		out.WriteRune(r)
+	}
+	return out.String()
+}
+
+func migrationIndex(config Config, name string) (int, error) {
+	for i, migration := range config.Migrations {
+		migrationType := reflect.TypeOf(migration)
+		if name == rem.MigrationId(migration) || name == migrationType.String() || name == migrationType.Name() {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("rem: unknown migration '%s'", name)
+}
+
+func printLogs(config Config, logs []string) {
+	for _, log := range logs {
+		fmt.Fprintln(config.Output, log)
+	}
+}
+
+func redo(config Config) error {
+	if config.MigrateConfig.DryRun {
+		// The second step would be planned against the state before the first.
+		return errors.New("rem: redo does not support dry runs")
+	}
+	states, err := rem.MigrationStatus(config.DB, config.Migrations)
+	if err != nil {
+		return err
+	}
+	id := ""
+	for _, state := range states {
+		if state.Applied && state.Migration != nil {
+			id = state.ID
+		}
+	}
+	if id == "" {
+		return errors.New("rem: no migrations have been applied")
+	}
+	logs, err := rem.MigrateDownN(config.DB, config.Migrations, 1, config.MigrateConfig)
+	printLogs(config, logs)
+	if err != nil {
+		return err
+	}
+	logs, err = rem.MigrateTo(config.DB, config.Migrations, id, config.MigrateConfig)
+	printLogs(config, logs)
+	return err
+}
+
+func status(config Config) error {
+	states, err := rem.MigrationStatus(config.DB, config.Migrations)
+	if err != nil {
+		return err
+	}
+	for _, state := range states {
+		switch {
+		case state.Migration == nil:
+			fmt.Fprintf(config.Output, "unknown  %s  %s\n", state.AppliedAt.Format("2006-01-02 15:04:05"), state.ID)
+		case state.Applied:
+			fmt.Fprintf(config.Output, "applied  %s  %s\n", state.AppliedAt.Format("2006-01-02 15:04:05"), state.ID)
+		default:
+			fmt.Fprintf(config.Output, "pending  %-19s  %s\n", "", state.ID)
+		}
+	}
+	return nil
+}
+
+func to(config Config, name string) error {
+	target, err := migrationIndex(config, name)
+	if err != nil {
+		return err
+	}
+	logs, err := rem.MigrateTo(config.DB, config.Migrations, rem.MigrationId(config.Migrations[target]), config.MigrateConfig)
+	printLogs(config, logs)
+	return err
+}
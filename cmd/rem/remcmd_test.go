@@ -0,0 +1,230 @@
+package remcmd
+
+import (
+	"bytes"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/evantbyrne/rem"
+	"github.com/evantbyrne/rem/sqlitedialect"
+)
+
+type testMigration0001 struct {
+	calls *[]string
+}
+
+func (m testMigration0001) Down(db *sql.DB) error {
+	*m.calls = append(*m.calls, "down 1")
+	return nil
+}
+
+func (m testMigration0001) Up(db *sql.DB) error {
+	*m.calls = append(*m.calls, "up 1")
+	return nil
+}
+
+type testMigration0002 struct {
+	calls *[]string
+}
+
+func (m testMigration0002) Down(db *sql.DB) error {
+	*m.calls = append(*m.calls, "down 2")
+	return nil
+}
+
+func (m testMigration0002) Up(db *sql.DB) error {
+	*m.calls = append(*m.calls, "up 2")
+	return nil
+}
+
+type testMigration0003 struct {
+	calls *[]string
+}
+
+func (m testMigration0003) Down(db *sql.DB) error {
+	*m.calls = append(*m.calls, "down 3")
+	return nil
+}
+
+func (m testMigration0003) Up(db *sql.DB) error {
+	*m.calls = append(*m.calls, "up 3")
+	return nil
+}
+
+func testMigrations(calls *[]string) []rem.Migration {
+	return []rem.Migration{
+		testMigration0001{calls: calls},
+		testMigration0002{calls: calls},
+		testMigration0003{calls: calls},
+	}
+}
+
+func TestDown(t *testing.T) {
+	rem.SetDialect(sqlitedialect.SqliteDialect{})
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	history := sqlmock.NewRows([]string{"checksum", "created_at", "direction", "id", "migration_type"}).
+		AddRow("", time.Now(), "up", 1, "remcmd.testMigration0001").
+		AddRow("", time.Now(), "up", 2, "remcmd.testMigration0002").
+		AddRow("", time.Now(), "up", 3, "remcmd.testMigration0003")
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `migrationlock`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO `migrationlock`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `migrationlogs`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT \\* FROM `migrationlogs` ORDER BY `id` ASC").WillReturnRows(history)
+	mock.ExpectExec("INSERT INTO `migrationlogs`").WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectExec("INSERT INTO `migrationlogs`").WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectExec("DELETE FROM `migrationlock`").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	calls := make([]string, 0)
+	var output bytes.Buffer
+	err = Run(Config{DB: db, Migrations: testMigrations(&calls), Output: &output}, []string{"down", "2"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if strings.Join(calls, ",") != "down 3,down 2" {
+		t.Errorf("Expected 'down 3,down 2', got '%s'", strings.Join(calls, ","))
+	}
+	expectedOutput := "Migrating down to remcmd.testMigration0003...\nMigrating down to remcmd.testMigration0002...\n"
+	if output.String() != expectedOutput {
+		t.Errorf("Expected '%s', got '%s'", expectedOutput, output.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	err = Run(Config{DB: db, Output: &output}, []string{"down", "x"})
+	if err == nil || err.Error() != "rem: invalid number of migrations 'x'" {
+		t.Errorf("Expected invalid number error, got '%v'", err)
+	}
+
+	err = Run(Config{DB: db, Output: &output}, []string{"down", "-lock-timeout", "soon"})
+	if err == nil || !strings.HasPrefix(err.Error(), "rem: invalid value \"soon\" for flag -lock-timeout") {
+		t.Errorf("Expected invalid flag error, got '%v'", err)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	rem.SetDialect(sqlitedialect.SqliteDialect{})
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	// Dry runs read the migration logs without taking the lock.
+	mock.ExpectQuery("SELECT \\* FROM `migrationlogs` ORDER BY `id` ASC").
+		WillReturnRows(sqlmock.NewRows([]string{"checksum", "created_at", "direction", "id", "migration_type"}).
+			AddRow("", time.Now(), "up", 1, "remcmd.testMigration0001").
+			AddRow("", time.Now(), "up", 2, "remcmd.testMigration0002").
+			AddRow("", time.Now(), "up", 3, "remcmd.testMigration0003"))
+
+	calls := make([]string, 0)
+	var output bytes.Buffer
+	config := Config{DB: db, Migrations: testMigrations(&calls), Output: &output}
+	if err := Run(config, []string{"down", "-dry-run", "1"}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if strings.Join(calls, ",") != "down 3" {
+		t.Errorf("Expected 'down 3', got '%s'", strings.Join(calls, ","))
+	}
+	if !strings.Contains(output.String(), "-- Migrating down to remcmd.testMigration0003...\n") {
+		t.Errorf("Expected script, got '%s'", output.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	err = Run(config, []string{"redo", "-dry-run"})
+	if err == nil || err.Error() != "rem: redo does not support dry runs" {
+		t.Errorf("Expected dry run error, got '%v'", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "migrations")
+	calls := make([]string, 0)
+	var output bytes.Buffer
+	config := Config{Dir: dir, Migrations: testMigrations(&calls), Output: &output}
+	if err := Run(config, []string{"new", "AddAccountsEmail"}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	path := filepath.Join(dir, "0004_add_accounts_email.go")
+	source, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	for _, expected := range []string{
+		"package migrations\n",
+		"type Migration0004AddAccountsEmail struct{}\n",
+		"func (m Migration0004AddAccountsEmail) Up(db *sql.DB) error {\n",
+		"func (m Migration0004AddAccountsEmail) Down(db *sql.DB) error {\n",
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Errorf("Expected '%s' in:\n%s", expected, source)
+		}
+	}
+	expectedOutput := "Created " + path + ". Add Migration0004AddAccountsEmail{} to the migrations list.\n"
+	if output.String() != expectedOutput {
+		t.Errorf("Expected '%s', got '%s'", expectedOutput, output.String())
+	}
+
+	err = Run(config, []string{"new", "add_accounts_email"})
+	if err == nil || err.Error() != "rem: migration file '"+path+"' already exists" {
+		t.Errorf("Expected file exists error, got '%v'", err)
+	}
+}
+
+func TestRunUnknown(t *testing.T) {
+	var output bytes.Buffer
+	err := Run(Config{Output: &output}, []string{"sideways"})
+	if err == nil || err.Error() != "rem: unknown command 'sideways'" {
+		t.Errorf("Expected unknown command error, got '%v'", err)
+	}
+	if !strings.HasPrefix(output.String(), "Usage:") {
+		t.Errorf("Expected usage, got '%s'", output.String())
+	}
+}
+
+func TestStatus(t *testing.T) {
+	rem.SetDialect(sqlitedialect.SqliteDialect{})
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `migrationlogs`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT \\* FROM `migrationlogs` ORDER BY `id` ASC").
+		WillReturnRows(sqlmock.NewRows([]string{"checksum", "created_at", "direction", "id", "migration_type"}).
+			AddRow("", createdAt, "up", 1, "remcmd.testMigration0001").
+			AddRow("", createdAt, "up", 2, "remcmd.testMigration0002").
+			AddRow("", createdAt, "down", 3, "remcmd.testMigration0002").
+			AddRow("", createdAt, "up", 4, "remcmd.testMigration0000"))
+
+	calls := make([]string, 0)
+	var output bytes.Buffer
+	if err := Run(Config{DB: db, Migrations: testMigrations(&calls), Output: &output}, []string{"status"}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	expected := "applied  2024-01-02 03:04:05  remcmd.testMigration0001\n" +
+		"pending                       remcmd.testMigration0002\n" +
+		"pending                       remcmd.testMigration0003\n" +
+		"unknown  2024-01-02 03:04:05  remcmd.testMigration0000\n"
+	if output.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, output.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
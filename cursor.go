@@ -0,0 +1,99 @@
+package rem
+
+type Cursor[T any] struct {
+	chunk   []*T
+	db      Executor
+	err     error
+	query   *Query[T]
+	row     *T
+	scanner *rowScanner[T]
+}
+
+func (cursor *Cursor[T]) Close() error {
+	cursor.chunk = nil
+	return cursor.query.Rows.Close()
+}
+
+func (cursor *Cursor[T]) contextErr() error {
+	if cursor.query.Config.Context != nil {
+		return cursor.query.Config.Context.Err()
+	}
+	return nil
+}
+
+func (cursor *Cursor[T]) Err() error {
+	if cursor.err != nil {
+		return cursor.err
+	}
+	return cursor.query.Rows.Err()
+}
+
+func (cursor *Cursor[T]) fill() bool {
+	chunkSize := 1
+	if len(cursor.query.fetchRelatedPaths()) > 0 {
+		chunkSize = cursor.query.Config.ChunkSize
+		if chunkSize < 1 {
+			chunkSize = 100
+		}
+	}
+
+	if cursor.scanner == nil {
+		columns, err := cursor.query.Rows.Columns()
+		if err != nil {
+			cursor.err = err
+			return false
+		}
+		cursor.scanner, err = newRowScanner(cursor.query.Model, columns)
+		if err != nil {
+			cursor.err = err
+			return false
+		}
+	}
+
+	chunk := make([]*T, 0, chunkSize)
+	for len(chunk) < chunkSize && cursor.query.Rows.Next() {
+		if err := cursor.contextErr(); err != nil {
+			cursor.err = err
+			return false
+		}
+		row, err := cursor.scanner.scan(cursor.query.Rows)
+		if err != nil {
+			cursor.err = err
+			return false
+		}
+		chunk = append(chunk, row)
+	}
+
+	if len(chunk) == 0 {
+		return false
+	}
+	if err := cursor.query.fetchRelated(cursor.db, chunk); err != nil {
+		cursor.err = err
+		return false
+	}
+	cursor.chunk = chunk
+	return true
+}
+
+func (cursor *Cursor[T]) Next() bool {
+	cursor.row = nil
+	if cursor.err != nil {
+		return false
+	}
+	if err := cursor.contextErr(); err != nil {
+		cursor.err = err
+		cursor.Close()
+		return false
+	}
+	if len(cursor.chunk) == 0 && !cursor.fill() {
+		cursor.Close()
+		return false
+	}
+	cursor.row = cursor.chunk[0]
+	cursor.chunk = cursor.chunk[1:]
+	return true
+}
+
+func (cursor *Cursor[T]) Row() *T {
+	return cursor.row
+}
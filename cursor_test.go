@@ -0,0 +1,115 @@
+package rem
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCursor(t *testing.T) {
+	defer func() {
+		defaultDialect = nil
+	}()
+	SetDialect(testDialect{})
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	model := Use[testAccountsQuerySlice]()
+
+	mock.ExpectQuery("SELECT|FILTER[]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "group_id"}).
+			AddRow(1, "foo", 10).
+			AddRow(2, "bar", nil))
+	cursor, err := model.Iter(db)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	ids := make([]int64, 0)
+	for cursor.Next() {
+		ids = append(ids, cursor.Row().Id)
+	}
+	if err := cursor.Err(); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Errorf("Expected '[1 2]', got '%+v'", ids)
+	}
+
+	// Fetch related in chunks.
+	mock.ExpectQuery("SELECT|FILTER[]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "group_id"}).
+			AddRow(1, "foo", 10).
+			AddRow(2, "bar", 20).
+			AddRow(3, "baz", 30))
+	mock.ExpectQuery("SELECT|FILTER[{Left:id Operator:IN Right:[10 20] Rule:WHERE}]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(10, "Group 10").
+			AddRow(20, "Group 20"))
+	mock.ExpectQuery("SELECT|FILTER[{Left:id Operator:IN Right:[30] Rule:WHERE}]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(30, "Group 30"))
+	cursor, err = model.Query().FetchRelated("Group").ChunkSize(2).Iter(db)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	groups := make([]string, 0)
+	for cursor.Next() {
+		groups = append(groups, cursor.Row().Group.Row.Name)
+	}
+	if err := cursor.Err(); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(groups) != 3 || groups[0] != "Group 10" || groups[1] != "Group 20" || groups[2] != "Group 30" {
+		t.Errorf("Expected '[Group 10 Group 20 Group 30]', got '%+v'", groups)
+	}
+
+	// Prefetching would query the connection while the result set is open.
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	_, err = model.Query().FetchRelated("Group").Iter(tx)
+	if err == nil || !strings.HasPrefix(err.Error(), "rem: FetchRelated can't be used with Iter on a *sql.Tx or *sql.Conn") {
+		t.Errorf("Expected FetchRelated error, got '%v'", err)
+	}
+	_, err = model.Query().Transaction(tx).FetchRelated("Group").Iter(db)
+	if err == nil || !strings.HasPrefix(err.Error(), "rem: FetchRelated can't be used with Iter on a *sql.Tx or *sql.Conn") {
+		t.Errorf("Expected FetchRelated error, got '%v'", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	// Context cancellation.
+	ctx, cancel := context.WithCancel(context.Background())
+	mock.ExpectQuery("SELECT|FILTER[]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "group_id"}).
+			AddRow(1, "foo", nil).
+			AddRow(2, "bar", nil))
+	cursor, err = model.Query().Context(ctx).Iter(db)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if !cursor.Next() || cursor.Row().Id != 1 {
+		t.Fatal("Expected first row before cancellation")
+	}
+	cancel()
+	if cursor.Next() {
+		t.Errorf("Expected Next to stop after cancellation, got '%+v'", cursor.Row())
+	}
+	if cursor.Err() != context.Canceled {
+		t.Errorf("Expected '%v', got '%v'", context.Canceled, cursor.Err())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,18 +1,31 @@
 package rem
 
 import (
+	"context"
+	"database/sql"
 	"reflect"
 )
 
+type CompositePrimaryColumnTyper interface {
+	CompositePrimaryColumnType(reflect.StructField) (string, error)
+}
+
 type Dialect interface {
 	BuildDelete(QueryConfig) (string, []interface{}, error)
 	BuildInsert(QueryConfig, map[string]interface{}, ...string) (string, []interface{}, error)
+	BuildInsertMany(QueryConfig, []map[string]interface{}, ...string) (string, []interface{}, error)
 	BuildSelect(QueryConfig) (string, []interface{}, error)
 	BuildTableColumnAdd(QueryConfig, string) (string, error)
+	BuildTableColumnAlter(QueryConfig, string) ([]string, error)
 	BuildTableColumnDrop(QueryConfig, string) (string, error)
+	BuildTableColumnRename(QueryConfig, string, string) (string, error)
 	BuildTableCreate(QueryConfig, TableCreateConfig) (string, error)
 	BuildTableDrop(QueryConfig, TableDropConfig) (string, error)
+	BuildTableIndexCreate(QueryConfig, string, TableIndexCreateConfig) (string, error)
+	BuildTableIndexDrop(QueryConfig, string, TableIndexDropConfig) (string, error)
+	BuildTableRename(QueryConfig, string) (string, error)
 	BuildUpdate(QueryConfig, map[string]interface{}, ...string) (string, []interface{}, error)
+	BuildUpsert(QueryConfig, map[string]interface{}, UpsertConfig, ...string) (string, []interface{}, error)
 	ColumnType(reflect.StructField) (string, error)
 	Param(i int) string
 	QuoteIdentifier(string) string
@@ -26,8 +39,36 @@ type DialectStringerWithArgs interface {
 	StringWithArgs(Dialect, []interface{}) (string, []interface{}, error)
 }
 
+type ParamLimiter interface {
+	ParamLimit() int
+}
+
+type RetryableErrorChecker interface {
+	IsRetryableError(error) bool
+}
+
+type ReturningSupporter interface {
+	SupportsReturning() bool
+}
+
+type TableColumnAlterer interface {
+	AlterTableColumn(ctx context.Context, db Executor, config QueryConfig, column string) (sql.Result, error)
+}
+
+type TransactionalDDLSupporter interface {
+	SupportsTransactionalDDL() bool
+}
+
+// Dialects that don't implement ParamLimiter are limited to SQLite's default.
+const defaultParamLimit = 999
+
 var defaultDialect Dialect
 
 func SetDialect(dialect Dialect) {
 	defaultDialect = dialect
 }
+
+func supportsReturning(dialect Dialect) bool {
+	returning, ok := dialect.(ReturningSupporter)
+	return ok && returning.SupportsReturning()
+}
@@ -3,51 +3,134 @@ package rem
 import (
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 )
 
 //lint:file-ignore U1000 Ignore report
-type testDialect struct{}
+type testDialect struct {
+	nonTransactionalDDL bool
+	returning           bool
+}
 
-func (dialect testDialect) BuildDelete(QueryConfig) (string, []interface{}, error) {
-	panic("Not implemented")
+func (dialect testDialect) BuildDelete(config QueryConfig) (string, []interface{}, error) {
+	return fmt.Sprintf("DELETE|%s|FILTER%+v|", config.Table, config.Filters), nil, nil
 }
 
-func (dialect testDialect) BuildInsert(QueryConfig, map[string]interface{}, ...string) (string, []interface{}, error) {
-	panic("Not implemented")
+func (dialect testDialect) BuildInsert(config QueryConfig, rowMap map[string]interface{}, columns ...string) (string, []interface{}, error) {
+	sort.Strings(columns)
+	args := make([]interface{}, 0)
+	for _, column := range columns {
+		args = append(args, rowMap[column])
+	}
+	return fmt.Sprintf("INSERT|%s|%s|RETURNING%s|", config.Table, strings.Join(columns, ","), strings.Join(config.Returning, ",")), args, nil
+}
+
+func (dialect testDialect) BuildInsertMany(config QueryConfig, rowMaps []map[string]interface{}, columns ...string) (string, []interface{}, error) {
+	args := make([]interface{}, 0)
+	for _, rowMap := range rowMaps {
+		for _, column := range columns {
+			args = append(args, rowMap[column])
+		}
+	}
+	return fmt.Sprintf("INSERT|%s|%s|ROWS%d|", config.Table, strings.Join(columns, ","), len(rowMaps)), args, nil
 }
 
 func (dialect testDialect) BuildSelect(config QueryConfig) (string, []interface{}, error) {
 	return fmt.Sprintf("SELECT|FILTER%+v|", config.Filters), nil, nil
 }
 
-func (dialect testDialect) BuildTableColumnAdd(QueryConfig, string) (string, error) {
-	panic("Not implemented")
+func (dialect testDialect) BuildTableColumnAdd(config QueryConfig, column string) (string, error) {
+	return fmt.Sprintf("ADD COLUMN|%s|%s|", config.Table, column), nil
+}
+
+func (dialect testDialect) BuildTableColumnAlter(config QueryConfig, column string) ([]string, error) {
+	return []string{fmt.Sprintf("ALTER COLUMN|%s|%s|", config.Table, column)}, nil
 }
 
 func (dialect testDialect) BuildTableColumnDrop(QueryConfig, string) (string, error) {
 	panic("Not implemented")
 }
 
-func (dialect testDialect) BuildTableCreate(QueryConfig, TableCreateConfig) (string, error) {
-	panic("Not implemented")
+func (dialect testDialect) BuildTableColumnRename(config QueryConfig, from string, to string) (string, error) {
+	return fmt.Sprintf("RENAME COLUMN|%s|%s|%s|", config.Table, from, to), nil
 }
 
-func (dialect testDialect) BuildTableDrop(QueryConfig, TableDropConfig) (string, error) {
-	panic("Not implemented")
+func (dialect testDialect) BuildTableCreate(config QueryConfig, tableCreateConfig TableCreateConfig) (string, error) {
+	columns := make([]string, 0, len(config.Fields))
+	for column := range config.Fields {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	return fmt.Sprintf("CREATE|%s|%s|%+v|", config.Table, strings.Join(columns, ","), tableCreateConfig), nil
 }
 
-func (dialect testDialect) BuildUpdate(QueryConfig, map[string]interface{}, ...string) (string, []interface{}, error) {
-	panic("Not implemented")
+func (dialect testDialect) BuildTableDrop(config QueryConfig, tableDropConfig TableDropConfig) (string, error) {
+	return fmt.Sprintf("DROP|%s|%+v|", config.Table, tableDropConfig), nil
+}
+
+func (dialect testDialect) BuildTableIndexCreate(config QueryConfig, name string, tableIndexCreateConfig TableIndexCreateConfig) (string, error) {
+	for _, index := range config.Indexes {
+		if index.Name == name {
+			return fmt.Sprintf("INDEX|%s|%+v|%+v|", config.Table, index, tableIndexCreateConfig), nil
+		}
+	}
+	return "", fmt.Errorf("rem: invalid index '%s' on model for table '%s'", name, config.Table)
+}
+
+func (dialect testDialect) BuildTableIndexDrop(config QueryConfig, name string, tableIndexDropConfig TableIndexDropConfig) (string, error) {
+	return fmt.Sprintf("DROP INDEX|%s|%s|%+v|", config.Table, name, tableIndexDropConfig), nil
+}
+
+func (dialect testDialect) BuildTableRename(config QueryConfig, name string) (string, error) {
+	return fmt.Sprintf("RENAME|%s|%s|", config.Table, name), nil
+}
+
+func (dialect testDialect) BuildUpdate(config QueryConfig, rowMap map[string]interface{}, columns ...string) (string, []interface{}, error) {
+	args := make([]interface{}, 0)
+	for _, column := range columns {
+		args = append(args, rowMap[column])
+	}
+	return fmt.Sprintf("UPDATE|%s|%s|FILTER%+v|RETURNING%s|", config.Table, strings.Join(columns, ","), config.Filters, strings.Join(config.Returning, ",")), args, nil
+}
+
+func (dialect testDialect) BuildUpsert(config QueryConfig, rowMap map[string]interface{}, upsertConfig UpsertConfig, columns ...string) (string, []interface{}, error) {
+	args := make([]interface{}, 0)
+	for _, column := range columns {
+		args = append(args, rowMap[column])
+	}
+	return fmt.Sprintf("UPSERT|%s|%s|%+v|RETURNING%s|", config.Table, strings.Join(columns, ","), upsertConfig, strings.Join(config.Returning, ",")), args, nil
 }
 
 func (dialect testDialect) ColumnType(reflect.StructField) (string, error) {
 	panic("Not implemented")
 }
 
+func (dialect testDialect) IsRetryableError(err error) bool {
+	return err != nil && err.Error() == "retryable"
+}
+
 func (dialect testDialect) Param(identifier int) string {
 	return fmt.Sprintf("$%d", identifier)
 }
 
+func (dialect testDialect) ParamLimit() int {
+	return 5
+}
+
 func (dialect testDialect) QuoteIdentifier(identifier string) string {
 	return fmt.Sprintf(`"%s"`, identifier)
 }
+
+func (dialect testDialect) SupportsReturning() bool {
+	return dialect.returning
+}
+
+func (dialect testDialect) SupportsTransactionalDDL() bool {
+	return !dialect.nonTransactionalDDL
+}
+
+// testBasicDialect only implements the required Dialect methods.
+type testBasicDialect struct {
+	Dialect
+}
@@ -0,0 +1,91 @@
+package rem
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestExecutor(t *testing.T) {
+	defer func() {
+		defaultDialect = nil
+	}()
+	SetDialect(testDialect{})
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	model := Use[testAccountsQuerySlice]()
+
+	// *sql.Tx
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT|FILTER[{Left:id Operator:= Right:1 Rule:WHERE}]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "group_id"}).
+			AddRow(1, "foo", nil))
+	mock.ExpectCommit()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	rows, err := model.Filter("id", "=", 1).All(tx)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(rows) != 1 || rows[0].Id != 1 || rows[0].Name != "foo" {
+		t.Errorf("Expected one row, got '%+v'", rows)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	// *sql.Conn
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	defer conn.Close()
+	mock.ExpectQuery("SELECT|FILTER[]|").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	count, err := model.Count(conn)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected '3', got '%d'", count)
+	}
+
+	// Exists closes its rows, which would otherwise hold the connection.
+	mock.ExpectQuery("SELECT|FILTER[]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "group_id"}).
+			AddRow(1, "foo", nil).
+			AddRow(2, "bar", nil)).
+		RowsWillBeClosed()
+	exists, err := model.Query().Exists(conn)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if !exists {
+		t.Error("Expected rows to exist")
+	}
+
+	// ForeignKey.Fetch
+	mock.ExpectQuery("SELECT|FILTER[{Left:id Operator:= Right:10 Rule:WHERE}]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(10, "Group 10"))
+	fk := NullForeignKey[testGroupsQuerySlice]{Row: &testGroupsQuerySlice{Id: 10}, Valid: true}
+	group, err := fk.Fetch(conn)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if group.Id != 10 || group.Name != "Group 10" {
+		t.Errorf("Expected 'Group 10', got '%+v'", group)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,7 +1,6 @@
 package rem
 
 import (
-	"database/sql"
 	"encoding/json"
 	"reflect"
 )
@@ -11,13 +10,15 @@ type ForeignKey[To any] struct {
 	Valid bool
 }
 
-func (fk *ForeignKey[To]) Fetch(db *sql.DB) (*To, error) {
+func (fk *ForeignKey[To]) Fetch(db Executor) (*To, error) {
 	query := &Query[To]{
 		Model: fk.Model(),
 	}
-	value := reflect.ValueOf(&fk.Row).Elem()
-	id := value.FieldByName(query.Model.PrimaryField).Interface()
-	return query.Filter("id", "=", id).First(db)
+	value := reflect.ValueOf(fk.Row).Elem()
+	for i, primaryColumn := range query.Model.PrimaryColumns {
+		query.Filter(primaryColumn, "=", value.FieldByName(query.Model.PrimaryFields[i]).Interface())
+	}
+	return query.First(db)
 }
 
 func (fk ForeignKey[To]) JsonValue() interface{} {
@@ -48,18 +49,28 @@ func (fk *ForeignKey[To]) Query() *Query[To] {
 	}
 }
 
+func (fk *ForeignKey[To]) relatedType() reflect.Type {
+	return reflect.TypeOf((*To)(nil)).Elem()
+}
+
+func (fk *ForeignKey[To]) relationKind() fieldKind {
+	return fieldForeignKey
+}
+
 type NullForeignKey[To any] struct {
 	Row   *To
 	Valid bool
 }
 
-func (fk *NullForeignKey[To]) Fetch(db *sql.DB) (*To, error) {
+func (fk *NullForeignKey[To]) Fetch(db Executor) (*To, error) {
 	query := &Query[To]{
-		Model: Use[To](),
+		Model: fk.Model(),
+	}
+	value := reflect.ValueOf(fk.Row).Elem()
+	for i, primaryColumn := range query.Model.PrimaryColumns {
+		query.Filter(primaryColumn, "=", value.FieldByName(query.Model.PrimaryFields[i]).Interface())
 	}
-	value := reflect.ValueOf(&fk.Row).Elem()
-	id := value.FieldByName(query.Model.PrimaryField).Interface()
-	return query.Filter("id", "=", id).First(db)
+	return query.First(db)
 }
 
 func (fk NullForeignKey[To]) JsonValue() interface{} {
@@ -89,3 +100,11 @@ func (fk *NullForeignKey[To]) Query() *Query[To] {
 		Model: Use[To](),
 	}
 }
+
+func (fk *NullForeignKey[To]) relatedType() reflect.Type {
+	return reflect.TypeOf((*To)(nil)).Elem()
+}
+
+func (fk *NullForeignKey[To]) relationKind() fieldKind {
+	return fieldNullForeignKey
+}
@@ -0,0 +1,138 @@
+package rem
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+type Index struct {
+	Columns []string
+	Method  string
+	Name    string
+	Unique  bool
+	Where   string
+}
+
+type Indexer interface {
+	Indexes() []Index
+}
+
+type TableIndexCreateConfig struct {
+	IfNotExists bool
+}
+
+type TableIndexDropConfig struct {
+	IfExists bool
+}
+
+func modelIndexes(modelType reflect.Type, table string, fields map[string]reflect.StructField) ([]Index, error) {
+	columns := make([]string, 0, len(fields))
+	for column, field := range fields {
+		if relation, ok := reflect.New(field.Type).Interface().(relationField); ok && relation.relationKind() != fieldForeignKey && relation.relationKind() != fieldNullForeignKey {
+			continue
+		}
+		if _, ok := field.Tag.Lookup("db_index"); ok {
+			columns = append(columns, column)
+		}
+	}
+	// Columns of composite indexes follow struct field order.
+	sort.SliceStable(columns, func(i, j int) bool {
+		a := fields[columns[i]].Index
+		b := fields[columns[j]].Index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return columns[i] < columns[j]
+	})
+
+	var err error
+	byName := make(map[string]*Index)
+	names := make([]string, 0)
+	for _, column := range columns {
+		field := fields[column]
+		fieldNames := make([]string, 0)
+		for _, name := range strings.Split(field.Tag.Get("db_index"), ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if name == "true" {
+				name = fmt.Sprintf("%s_%s_idx", table, column)
+			}
+			fieldNames = append(fieldNames, name)
+		}
+
+		// A value of true applies to every index on the field, otherwise the value lists index names.
+		unique := make(map[string]bool)
+		if value := field.Tag.Get("db_index_unique"); value == "true" {
+			for _, name := range fieldNames {
+				unique[name] = true
+			}
+		} else if value != "" && value != "false" {
+			for _, name := range strings.Split(value, ",") {
+				name = strings.TrimSpace(name)
+				if !slices.Contains(fieldNames, name) {
+					err = errors.Join(err, fmt.Errorf("rem: db_index_unique on field '%s' names index '%s', which isn't in its db_index tag", field.Name, name))
+					continue
+				}
+				unique[name] = true
+			}
+		}
+
+		// Methods and conditions can't be scoped to one of several indexes, so they're only allowed on fields with a single index.
+		method := field.Tag.Get("db_index_method")
+		where := field.Tag.Get("db_index_where")
+		if len(fieldNames) > 1 && (method != "" || where != "") {
+			err = errors.Join(err, fmt.Errorf("rem: db_index_method and db_index_where on field '%s' would apply to each of its indexes %v. Declare them with Indexer instead", field.Name, fieldNames))
+		}
+
+		for _, name := range fieldNames {
+			index, ok := byName[name]
+			if !ok {
+				index = &Index{Name: name}
+				byName[name] = index
+				names = append(names, name)
+			}
+			index.Columns = append(index.Columns, column)
+			if unique[name] {
+				index.Unique = true
+			}
+			if method != "" {
+				if index.Method != "" && index.Method != method {
+					err = errors.Join(err, fmt.Errorf("rem: conflicting db_index_method values '%s' and '%s' on index '%s'", index.Method, method, name))
+				}
+				index.Method = method
+			}
+			if where != "" {
+				if index.Where != "" && index.Where != where {
+					err = errors.Join(err, fmt.Errorf("rem: conflicting db_index_where values '%s' and '%s' on index '%s'", index.Where, where, name))
+				}
+				index.Where = where
+			}
+		}
+	}
+
+	indexes := make([]Index, 0, len(names))
+	for _, name := range names {
+		indexes = append(indexes, *byName[name])
+	}
+	if modelType != nil {
+		if indexer, ok := reflect.New(modelType).Interface().(Indexer); ok {
+			indexes = append(indexes, indexer.Indexes()...)
+		}
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return indexes[i].Name < indexes[j].Name
+	})
+	return indexes, err
+}
@@ -0,0 +1,131 @@
+package rem
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type testAccountsIndexes struct {
+	Email   string `db:"email" db_index:"testaccountsindexes_email_idx" db_index_unique:"true"`
+	GroupId int64  `db:"group_id" db_index:"true,testaccountsindexes_group_name_idx"`
+	Id      int64  `db:"id" db_primary:"true"`
+	Name    string `db:"name" db_index:"testaccountsindexes_group_name_idx"`
+}
+
+func (accounts testAccountsIndexes) Indexes() []Index {
+	return []Index{
+		{Columns: []string{"name"}, Method: "GIN", Name: "testaccountsindexes_custom_idx", Where: "name <> ''"},
+	}
+}
+
+func TestIndexes(t *testing.T) {
+	defer func() {
+		defaultDialect = nil
+	}()
+	SetDialect(testDialect{})
+
+	model := Use[testAccountsIndexes]()
+	expected := []Index{
+		{Columns: []string{"name"}, Method: "GIN", Name: "testaccountsindexes_custom_idx", Where: "name <> ''"},
+		{Columns: []string{"email"}, Name: "testaccountsindexes_email_idx", Unique: true},
+		{Columns: []string{"group_id"}, Name: "testaccountsindexes_group_id_idx"},
+		{Columns: []string{"group_id", "name"}, Name: "testaccountsindexes_group_name_idx"},
+	}
+	if !reflect.DeepEqual(model.Indexes, expected) {
+		t.Errorf("Expected '%+v', got '%+v'", expected, model.Indexes)
+	}
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("CREATE|testaccountsindexes|email,group_id,id,name|{IfNotExists:true Indexes:true}|").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	for _, index := range expected {
+		mock.ExpectExec("INDEX|testaccountsindexes|" + fmt.Sprintf("%+v", index) + "|{IfNotExists:true}|").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	if _, err := model.TableCreate(db, TableCreateConfig{IfNotExists: true, Indexes: true}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	mock.ExpectExec("INDEX|testaccountsindexes|" + fmt.Sprintf("%+v", expected[1]) + "|{IfNotExists:false}|").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	if _, err := model.TableIndexCreate(db, "testaccountsindexes_email_idx"); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	mock.ExpectExec("DROP INDEX|testaccountsindexes|testaccountsindexes_email_idx|{IfExists:true}|").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	if _, err := model.TableIndexDrop(db, "testaccountsindexes_email_idx", TableIndexDropConfig{IfExists: true}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	if _, err := model.TableIndexCreate(db, "bogus"); err == nil || err.Error() != "rem: invalid index 'bogus' on model for table 'testaccountsindexes'" {
+		t.Errorf("Expected invalid index error, got '%v'", err)
+	}
+
+	// Dialects with introspection skip existing indexes instead of relying on IF NOT EXISTS.
+	mock.ExpectExec("CREATE|testaccountsindexes|email,group_id,id,name|{IfNotExists:true Indexes:true}|").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	for _, index := range expected[1:] {
+		mock.ExpectExec("INDEX|testaccountsindexes|" + fmt.Sprintf("%+v", index) + "|{IfNotExists:false}|").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	introspector := testIntrospectorDialect{schemas: map[string]*TableSchema{
+		"testaccountsindexes": {Indexes: []Index{expected[0]}, Name: "testaccountsindexes"},
+	}}
+	if _, err := model.Dialect(introspector).TableCreate(db, TableCreateConfig{IfNotExists: true, Indexes: true}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIndexesOptions(t *testing.T) {
+	type testModel struct {
+		Email string `db:"email" db_index:"true,testmodel_email_name_idx" db_index_unique:"testmodel_email_name_idx"`
+		Id    int64  `db:"id" db_primary:"true"`
+		Name  string `db:"name" db_index:"testmodel_email_name_idx"`
+	}
+	model := Use[testModel]()
+	if model.Error != nil {
+		t.Fatal("Unexpected error:", model.Error)
+	}
+	expected := []Index{
+		{Columns: []string{"email"}, Name: "testmodel_email_idx"},
+		{Columns: []string{"email", "name"}, Name: "testmodel_email_name_idx", Unique: true},
+	}
+	if !reflect.DeepEqual(model.Indexes, expected) {
+		t.Errorf("Expected '%+v', got '%+v'", expected, model.Indexes)
+	}
+
+	type testModelUnknown struct {
+		Email string `db:"email" db_index:"true" db_index_unique:"bogus"`
+	}
+	if err := Use[testModelUnknown]().Error; err == nil || err.Error() != "rem: db_index_unique on field 'Email' names index 'bogus', which isn't in its db_index tag" {
+		t.Errorf("Expected db_index_unique error, got '%v'", err)
+	}
+
+	type testModelAmbiguous struct {
+		Email string `db:"email" db_index:"true,testmodelambiguous_pair_idx" db_index_where:"email <> ''"`
+	}
+	if err := Use[testModelAmbiguous]().Error; err == nil || err.Error() != "rem: db_index_method and db_index_where on field 'Email' would apply to each of its indexes [testmodelambiguous_email_idx testmodelambiguous_pair_idx]. Declare them with Indexer instead" {
+		t.Errorf("Expected ambiguous options error, got '%v'", err)
+	}
+
+	type testModelConflict struct {
+		Email string `db:"email" db_index:"testmodelconflict_pair_idx" db_index_method:"GIN"`
+		Name  string `db:"name" db_index:"testmodelconflict_pair_idx" db_index_method:"BTREE"`
+	}
+	if err := Use[testModelConflict]().Error; err == nil || err.Error() != "rem: conflicting db_index_method values 'GIN' and 'BTREE' on index 'testmodelconflict_pair_idx'" {
+		t.Errorf("Expected conflicting method error, got '%v'", err)
+	}
+}
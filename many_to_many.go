@@ -0,0 +1,274 @@
+package rem
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+type ManyToMany[To any] struct {
+	RelatedColumn string
+	RowPk         interface{}
+	Rows          []*To
+	ThroughTable  string
+	ToColumn      string
+}
+
+func (field *ManyToMany[To]) Add(db Executor, rows ...*To) error {
+	if err := field.validate(); err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	toPks, err := field.toPks(rows)
+	if err != nil {
+		return err
+	}
+	// Rows passed more than once are linked once. Rows that are already linked violate the through table's primary key.
+	throughRows := make([]*manyToManyThrough, 0, len(toPks))
+	seen := make(map[interface{}]bool)
+	for _, toPk := range toPks {
+		if reflect.TypeOf(toPk).Comparable() {
+			if seen[toPk] {
+				continue
+			}
+			seen[toPk] = true
+		}
+		throughRows = append(throughRows, &manyToManyThrough{RelatedPk: field.RowPk, ToPk: toPk})
+	}
+	_, err = field.throughQuery().InsertMany(db, throughRows)
+	return err
+}
+
+func (field *ManyToMany[To]) All(db Executor) ([]*To, error) {
+	if err := field.validate(); err != nil {
+		return nil, err
+	}
+	return field.Query().Filter(field.ThroughTable+"."+field.RelatedColumn, "=", field.RowPk).All(db)
+}
+
+func (field *ManyToMany[To]) fetchManyToMany(db Executor, dialect Dialect, config QueryConfig, column string, fn interface{}, relatedPkType reflect.Type, relatedValues []interface{}) (map[interface{}]reflect.Value, error) {
+	query := field.Query()
+	query.Config.Context = config.Context
+	query.Config.FetchRelated = config.FetchRelated
+	query.Config.FetchRelatedQueries = config.FetchRelatedQueries
+	query.dialect = dialect
+	if fn != nil {
+		fnQuery, ok := fn.(func(*Query[To]) *Query[To])
+		if !ok {
+			return nil, fmt.Errorf("rem: invalid query function for fetching related on field '%s'. Expected func(%T) %T", column, query, query)
+		}
+		query = fnQuery(query)
+		if query.Config.Limit != nil || query.Config.Offset != nil {
+			return nil, fmt.Errorf("rem: Limit and Offset can't be used in the query function for fetching related on field '%s', because they would apply to all parent rows together", column)
+		}
+	}
+	query.Config.Selected = append(query.Config.Selected, As(Column(field.ThroughTable+"."+field.RelatedColumn), manyToManyAlias))
+	query.Filter(field.ThroughTable+"."+field.RelatedColumn, "IN", relatedValues)
+	query.detectDialect()
+	if err := query.configure(); err != nil {
+		return nil, err
+	}
+
+	queryString, args, err := query.dialect.BuildSelect(query.Config)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := query.dbQuery(db, queryString, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Scan with a copy of the model that accepts the related primary key alias.
+	scanModel := *query.Model
+	scanModel.Fields = make(map[string]reflect.StructField, len(query.Model.Fields)+1)
+	for column, structField := range query.Model.Fields {
+		scanModel.Fields[column] = structField
+	}
+	scanModel.Fields[manyToManyAlias] = reflect.StructField{Type: relatedPkType}
+	scanModel.meta = nil
+
+	related := make(map[interface{}]reflect.Value)
+	allRows := make([]*To, 0)
+	for rows.Next() {
+		data, err := scanModel.ScanToMap(rows)
+		if err != nil {
+			return nil, err
+		}
+		row, err := query.Model.ScanMap(data)
+		if err != nil {
+			return nil, err
+		}
+		relatedPk := data[manyToManyAlias]
+		if _, ok := related[relatedPk]; !ok {
+			related[relatedPk] = reflect.ValueOf(make([]*To, 0))
+		}
+		related[relatedPk] = reflect.Append(related[relatedPk], reflect.ValueOf(row))
+		allRows = append(allRows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := query.fetchRelated(db, allRows); err != nil {
+		return nil, err
+	}
+	return related, nil
+}
+
+func (field ManyToMany[To]) JsonValue() interface{} {
+	model := field.Model()
+	results := make([]map[string]interface{}, len(field.Rows))
+	for i := range field.Rows {
+		results[i] = model.ToJsonMap(field.Rows[i])
+	}
+	return results
+}
+
+func (field ManyToMany[To]) MarshalJSON() ([]byte, error) {
+	model := field.Model()
+	results := make([]map[string]interface{}, len(field.Rows))
+	for i, row := range field.Rows {
+		results[i] = model.ToJsonMap(row)
+	}
+	return json.Marshal(results)
+}
+
+func (field *ManyToMany[To]) Model() *Model[To] {
+	return Use[To]()
+}
+
+func (field *ManyToMany[To]) Query() *Query[To] {
+	model := field.Model()
+	query := &Query[To]{
+		Model: model,
+	}
+	return query.
+		Select(sqlTableColumns(model.Table)).
+		Join(field.ThroughTable, Q(field.ThroughTable+"."+field.ToColumn, "=", Column(model.Table+"."+model.PrimaryColumn)))
+}
+
+func (field *ManyToMany[To]) relatedType() reflect.Type {
+	return reflect.TypeOf((*To)(nil)).Elem()
+}
+
+func (field *ManyToMany[To]) relationKind() fieldKind {
+	return fieldManyToMany
+}
+
+func (field *ManyToMany[To]) Remove(db Executor, rows ...*To) error {
+	if err := field.validate(); err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	toPks, err := field.toPks(rows)
+	if err != nil {
+		return err
+	}
+	_, err = field.throughQuery().
+		Filter(field.RelatedColumn, "=", field.RowPk).
+		Filter(field.ToColumn, "IN", toPks).
+		Delete(db)
+	return err
+}
+
+func (field *ManyToMany[To]) Set(db Executor, rows ...*To) error {
+	if err := field.validate(); err != nil {
+		return err
+	}
+	// The links are replaced atomically, using a savepoint when db is already a transaction.
+	return InTransaction(context.Background(), db, nil, func(tx *sql.Tx) error {
+		_, err := field.throughQuery().
+			Filter(field.RelatedColumn, "=", field.RowPk).
+			Delete(tx)
+		if err != nil {
+			return err
+		}
+		return field.Add(tx, rows...)
+	})
+}
+
+func (field *ManyToMany[To]) throughModel(relatedType reflect.Type) *Model[manyToManyThrough] {
+	return &Model[manyToManyThrough]{
+		Fields: map[string]reflect.StructField{
+			field.RelatedColumn: {
+				Index: []int{0},
+				Name:  "RelatedPk",
+				Tag:   reflect.StructTag(fmt.Sprintf(`db:"%s" db_on_delete:"CASCADE" db_primary:"true"`, field.RelatedColumn)),
+				Type:  relatedType,
+			},
+			field.ToColumn: {
+				Index: []int{1},
+				Name:  "ToPk",
+				Tag:   reflect.StructTag(fmt.Sprintf(`db:"%s" db_on_delete:"CASCADE" db_primary:"true"`, field.ToColumn)),
+				Type:  reflect.TypeOf(ForeignKey[To]{}),
+			},
+		},
+		// Each pair of rows is linked at most once.
+		PrimaryColumns: []string{field.RelatedColumn, field.ToColumn},
+		Table:          field.ThroughTable,
+		Type:           reflect.TypeOf(manyToManyThrough{}),
+	}
+}
+
+func (field *ManyToMany[To]) throughQuery() *Query[manyToManyThrough] {
+	return &Query[manyToManyThrough]{
+		Model: field.throughModel(reflect.TypeOf(field.RowPk)),
+	}
+}
+
+func (field *ManyToMany[To]) toPks(rows []*To) ([]interface{}, error) {
+	model := field.Model()
+	toPks := make([]interface{}, len(rows))
+	for i, row := range rows {
+		if row == nil {
+			return nil, fmt.Errorf("rem: nil row for many-to-many through table '%s'", field.ThroughTable)
+		}
+		toPks[i] = reflect.ValueOf(row).Elem().FieldByName(model.PrimaryField).Interface()
+	}
+	return toPks, nil
+}
+
+func (field *ManyToMany[To]) validate() error {
+	if field.ThroughTable == "" || field.RelatedColumn == "" || field.ToColumn == "" {
+		return fmt.Errorf("rem: many-to-many field is not initialized. Fetch or insert the row before using the relation")
+	}
+	if field.RowPk == nil || reflect.ValueOf(field.RowPk).IsZero() {
+		return fmt.Errorf("rem: many-to-many field for through table '%s' is missing the row primary key", field.ThroughTable)
+	}
+	return nil
+}
+
+type manyToManyField interface {
+	fetchManyToMany(db Executor, dialect Dialect, config QueryConfig, column string, fn interface{}, relatedPkType reflect.Type, relatedValues []interface{}) (map[interface{}]reflect.Value, error)
+	throughModel(relatedType reflect.Type) *Model[manyToManyThrough]
+}
+
+func newManyToManyField(field reflect.StructField) manyToManyField {
+	manyToMany := reflect.New(field.Type)
+	manyToMany.Elem().FieldByName("RelatedColumn").SetString(field.Tag.Get("db"))
+	manyToMany.Elem().FieldByName("ThroughTable").SetString(field.Tag.Get("db_through"))
+	manyToMany.Elem().FieldByName("ToColumn").SetString(field.Tag.Get("db_to"))
+	return manyToMany.Interface().(manyToManyField)
+}
+
+type manyToManyThrough struct {
+	RelatedPk interface{}
+	ToPk      interface{}
+}
+
+const manyToManyAlias = "rem_related_pk"
+
+type sqlTableColumns string
+
+func (table sqlTableColumns) StringForDialect(dialect Dialect) string {
+	return dialect.QuoteIdentifier(string(table)) + ".*"
+}
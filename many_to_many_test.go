@@ -0,0 +1,200 @@
+package rem
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type testTagsManyToMany struct {
+	Id   int64  `db:"id" db_primary:"true"`
+	Name string `db:"name"`
+}
+
+type testPostsManyToMany struct {
+	Id    int64                          `db:"id" db_primary:"true"`
+	Tags  ManyToMany[testTagsManyToMany] `db:"post_id" db_through:"post_tags" db_to:"tag_id"`
+	Title string                         `db:"title"`
+}
+
+func TestManyToMany(t *testing.T) {
+	defer func() {
+		defaultDialect = nil
+	}()
+	SetDialect(testDialect{})
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	// Prefetch.
+	mock.ExpectQuery("SELECT|FILTER[]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).
+			AddRow(1, "foo").
+			AddRow(2, "bar").
+			AddRow(3, "baz"))
+	mock.ExpectQuery("SELECT|FILTER[{Left:post_tags.post_id Operator:IN Right:[1 2 3] Rule:WHERE}]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "rem_related_pk"}).
+			AddRow(10, "Tag 10", 1).
+			AddRow(20, "Tag 20", 1).
+			AddRow(10, "Tag 10", 2))
+	posts, err := Use[testPostsManyToMany]().FetchRelated("Tags").All(db)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(posts) != 3 ||
+		len(posts[0].Tags.Rows) != 2 ||
+		posts[0].Tags.Rows[0].Name != "Tag 10" ||
+		posts[0].Tags.Rows[1].Name != "Tag 20" ||
+		len(posts[1].Tags.Rows) != 1 ||
+		posts[1].Tags.Rows[0].Id != 10 ||
+		posts[2].Tags.Rows == nil ||
+		len(posts[2].Tags.Rows) != 0 {
+		t.Errorf("Expected prefetched tags, got '%+v'", posts)
+	}
+
+	// Prefetch with Offset.
+	mock.ExpectQuery("SELECT|FILTER[]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).
+			AddRow(1, "foo"))
+	_, err = Use[testPostsManyToMany]().
+		FetchRelatedQuery("Tags", func(q *Query[testTagsManyToMany]) *Query[testTagsManyToMany] {
+			return q.Offset(1)
+		}).
+		All(db)
+	if err == nil || err.Error() != "rem: Limit and Offset can't be used in the query function for fetching related on field 'Tags', because they would apply to all parent rows together" {
+		t.Errorf("Expected Offset error, got '%v'", err)
+	}
+
+	// Lazy fetch.
+	post := posts[1]
+	mock.ExpectQuery("SELECT|FILTER[{Left:post_tags.post_id Operator:= Right:2 Rule:WHERE}]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(10, "Tag 10"))
+	tags, err := post.Tags.All(db)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(tags) != 1 || tags[0].Id != 10 {
+		t.Errorf("Expected one tag, got '%+v'", tags)
+	}
+
+	// Add.
+	mock.ExpectExec("INSERT|post_tags|post_id,tag_id|ROWS2|").
+		WithArgs(2, 20, 2, 30).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	if err := post.Tags.Add(db, &testTagsManyToMany{Id: 20}, &testTagsManyToMany{Id: 30}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	// Remove.
+	mock.ExpectExec("DELETE|post_tags|FILTER[{Left:post_id Operator:= Right:2 Rule:WHERE} {Left:<nil> Operator: Right:<nil> Rule:AND} {Left:tag_id Operator:IN Right:[20] Rule:WHERE}]|").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := post.Tags.Remove(db, &testTagsManyToMany{Id: 20}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	// Add links rows passed more than once a single time.
+	mock.ExpectExec("INSERT|post_tags|post_id,tag_id|ROWS1|").
+		WithArgs(2, 50).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := post.Tags.Add(db, &testTagsManyToMany{Id: 50}, &testTagsManyToMany{Id: 50}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	// Set.
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE|post_tags|FILTER[{Left:post_id Operator:= Right:2 Rule:WHERE}]|").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT|post_tags|post_id,tag_id|ROWS1|").
+		WithArgs(2, 40).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := post.Tags.Set(db, &testTagsManyToMany{Id: 40}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	// Set rolls back the delete when linking fails.
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE|post_tags|FILTER[{Left:post_id Operator:= Right:2 Rule:WHERE}]|").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT|post_tags|post_id,tag_id|ROWS1|").
+		WithArgs(2, 60).
+		WillReturnError(errors.New("failed"))
+	mock.ExpectRollback()
+	if err := post.Tags.Set(db, &testTagsManyToMany{Id: 60}); err == nil || err.Error() != "failed" {
+		t.Errorf("Expected 'failed', got '%v'", err)
+	}
+
+	// Set within a transaction uses a savepoint.
+	savepoint := fmt.Sprintf("rem_savepoint_%d", savepointCounter.Load()+1)
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT " + savepoint).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE|post_tags|FILTER[{Left:post_id Operator:= Right:2 Rule:WHERE}]|").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT|post_tags|post_id,tag_id|ROWS1|").
+		WithArgs(2, 40).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("RELEASE SAVEPOINT " + savepoint).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err := post.Tags.Set(tx, &testTagsManyToMany{Id: 40}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	// Uninitialized.
+	var empty testPostsManyToMany
+	if err := empty.Tags.Add(db, &testTagsManyToMany{Id: 40}); err == nil {
+		t.Error("Expected error for uninitialized many-to-many field")
+	}
+
+	// Table create and drop leave the join table to explicit calls.
+	mock.ExpectExec("CREATE|testpostsmanytomany|id,title|{IfNotExists:false Indexes:false}|").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	if _, err := Use[testPostsManyToMany]().TableCreate(db); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	mock.ExpectExec("CREATE|post_tags|post_id,tag_id|{IfNotExists:true Indexes:false}|").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	if _, err := Use[testPostsManyToMany]().TableThroughCreate(db, "Tags", TableCreateConfig{IfNotExists: true}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	mock.ExpectExec("DROP|post_tags|{IfExists:true}|").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	if _, err := Use[testPostsManyToMany]().TableThroughDrop(db, "Tags", TableDropConfig{IfExists: true}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	mock.ExpectExec("DROP|testpostsmanytomany|{IfExists:false}|").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	if _, err := Use[testPostsManyToMany]().TableDrop(db); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if _, err := Use[testPostsManyToMany]().TableThroughCreate(db, "Title"); err == nil || err.Error() != "rem: invalid field 'Title' for join table. Field must be of type rem.ManyToMany[To]" {
+		t.Errorf("Expected invalid field error, got '%v'", err)
+	}
+
+	throughQuery, err := Use[testPostsManyToMany]().Query().throughQuery("Tags")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if strings.Join(throughQuery.Model.PrimaryColumns, ",") != "post_id,tag_id" {
+		t.Errorf("Expected composite primary key 'post_id,tag_id', got '%s'", strings.Join(throughQuery.Model.PrimaryColumns, ","))
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,104 +1,457 @@
 package rem
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"reflect"
 	"time"
+
+	"golang.org/x/exp/slices"
 )
 
+var ErrMigrationLockTimeout = errors.New("rem: timed out waiting for the migration lock")
+
+type MigrateConfig struct {
+	DryRun      bool
+	LockTimeout time.Duration
+}
+
 type Migration interface {
 	Down(db *sql.DB) error
 	Up(db *sql.DB) error
 }
 
+type MigrationIdentifier interface {
+	ID() string
+}
+
+type MigrationLocker interface {
+	LockMigrations(ctx context.Context, db *sql.DB, timeout time.Duration) (func() error, error)
+}
+
 type MigrationLogs struct {
+	Checksum      string    `db:"checksum" db_default:"''" db_max_length:"64"`
 	CreatedAt     time.Time `db:"created_at"`
 	Direction     string    `db:"direction" db_max_length:"10"`
 	Id            int64     `db:"id" db_primary:"true"`
 	MigrationType string    `db:"migration_type" db_max_length:"255"`
 }
 
-func MigrateDown(db *sql.DB, migrations []Migration) ([]string, error) {
-	logs, latestIndex, err := migrateSetup(db, migrations)
-	if err != nil {
-		return logs, err
+type MigrationState struct {
+	Applied   bool
+	AppliedAt time.Time
+	ID        string
+	Migration Migration
+}
+
+type MigrationTx interface {
+	Down(ctx context.Context, tx *sql.Tx) error
+	Up(ctx context.Context, tx *sql.Tx) error
+}
+
+func MigrateDown(db *sql.DB, migrations []Migration, config ...MigrateConfig) ([]string, error) {
+	return MigrateDownContext(context.Background(), db, migrations, config...)
+}
+
+func MigrateDownContext(ctx context.Context, db *sql.DB, migrations []Migration, config ...MigrateConfig) ([]string, error) {
+	return migrate(ctx, db, migrations, func(latestIndex int) int {
+		return -1
+	}, config)
+}
+
+func MigrateDownN(db *sql.DB, migrations []Migration, n int, config ...MigrateConfig) ([]string, error) {
+	return MigrateDownNContext(context.Background(), db, migrations, n, config...)
+}
+
+func MigrateDownNContext(ctx context.Context, db *sql.DB, migrations []Migration, n int, config ...MigrateConfig) ([]string, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("rem: invalid number of migrations %d", n)
+	}
+	return migrate(ctx, db, migrations, func(latestIndex int) int {
+		return max(latestIndex-n, -1)
+	}, config)
+}
+
+func MigrateTo(db *sql.DB, migrations []Migration, id string, config ...MigrateConfig) ([]string, error) {
+	return MigrateToContext(context.Background(), db, migrations, id, config...)
+}
+
+func MigrateToContext(ctx context.Context, db *sql.DB, migrations []Migration, id string, config ...MigrateConfig) ([]string, error) {
+	for i, migration := range migrations {
+		if MigrationId(migration) == id {
+			return migrate(ctx, db, migrations, func(latestIndex int) int {
+				return i
+			}, config)
+		}
 	}
+	return nil, fmt.Errorf("rem: unknown migration '%s'", id)
+}
+
+func MigrateUp(db *sql.DB, migrations []Migration, config ...MigrateConfig) ([]string, error) {
+	return MigrateUpContext(context.Background(), db, migrations, config...)
+}
+
+func MigrateUpContext(ctx context.Context, db *sql.DB, migrations []Migration, config ...MigrateConfig) ([]string, error) {
+	return migrate(ctx, db, migrations, func(latestIndex int) int {
+		return len(migrations) - 1
+	}, config)
+}
+
+func MigrationId(migration Migration) string {
+	if identifier, ok := migration.(MigrationIdentifier); ok {
+		return identifier.ID()
+	}
+	return reflect.TypeOf(migration).String()
+}
+
+func MigrationRelabel(db *sql.DB, migrations []Migration, labels map[string]string) ([]string, error) {
 	migrationLogs := Use[MigrationLogs]()
+	if _, err := migrationLogs.TableCreate(db, TableCreateConfig{IfNotExists: true}); err != nil {
+		return nil, errors.Join(errors.New("rem: migrations relabel: failed to create table for migration logs"), err)
+	}
+	rows, err := migrationLogRows(context.Background(), db, db)
+	if err != nil {
+		return nil, errors.Join(errors.New("rem: migrations relabel: failed to get migrations list"), err)
+	}
 
-	for i := latestIndex; i > -1; i-- {
-		migrationType := reflect.TypeOf(migrations[i]).String()
-		logs = append(logs, "Migrating down to "+migrationType+"...")
-		if err := migrations[i].Down(db); err != nil {
-			return logs, errors.Join(fmt.Errorf("migration %s: failed", migrationType), err)
-		}
-		_, err := migrationLogs.Insert(db, &MigrationLogs{
-			CreatedAt:     time.Now(),
-			Direction:     "down",
-			MigrationType: migrationType,
-		})
+	// Logs recorded before a migration implemented ID() are labeled with the Go type.
+	relabels := make(map[string]string, len(labels))
+	ids := make([]string, len(migrations))
+	for i, migration := range migrations {
+		ids[i] = MigrationId(migration)
+		if typeName := reflect.TypeOf(migration).String(); typeName != ids[i] {
+			relabels[typeName] = ids[i]
+		}
+	}
+	for from, to := range labels {
+		relabels[from] = to
+	}
+
+	logs := make([]string, 0)
+	for _, row := range rows {
+		label := row.MigrationType
+		if relabel, ok := relabels[label]; ok {
+			label = relabel
+		}
+		checksum := row.Checksum
+		if i := slices.Index(ids, label); i > -1 {
+			checksum = migrationChecksum(ids[:i+1])
+		}
+		if label == row.MigrationType && checksum == row.Checksum {
+			continue
+		}
+		logs = append(logs, fmt.Sprintf("Relabeling migration log %d from %s to %s...", row.Id, row.MigrationType, label))
+		row.Checksum = checksum
+		row.MigrationType = label
+		if _, err := migrationLogs.Filter("id", "=", row.Id).Update(db, row, "checksum", "migration_type"); err != nil {
+			return logs, errors.Join(fmt.Errorf("rem: migrations relabel: failed to update migration log %d", row.Id), err)
+		}
+	}
+	return logs, nil
+}
+
+func MigrationStatus(db *sql.DB, migrations []Migration) ([]MigrationState, error) {
+	return MigrationStatusContext(context.Background(), db, migrations)
+}
+
+func MigrationStatusContext(ctx context.Context, db *sql.DB, migrations []Migration) ([]MigrationState, error) {
+	if _, err := Use[MigrationLogs]().Context(ctx).TableCreate(db, TableCreateConfig{IfNotExists: true}); err != nil {
+		return nil, errors.Join(errors.New("rem: migrations status: failed to create table for migration logs"), err)
+	}
+	rows, err := migrationLogRows(ctx, db, db)
+	if err != nil {
+		return nil, errors.Join(errors.New("rem: migrations status: failed to get migrations list"), err)
+	}
+
+	applied := migrationApplied(rows)
+
+	states := make([]MigrationState, len(migrations))
+	for i, migration := range migrations {
+		states[i].ID = MigrationId(migration)
+		states[i].Migration = migration
+		if row, ok := applied[states[i].ID]; ok {
+			states[i].Applied = true
+			states[i].AppliedAt = row.CreatedAt
+			delete(applied, states[i].ID)
+		}
+	}
+
+	// Applied migrations missing from the list are included without a Migration.
+	for _, row := range rows {
+		if row, ok := applied[row.MigrationType]; ok {
+			states = append(states, MigrationState{
+				Applied:   true,
+				AppliedAt: row.CreatedAt,
+				ID:        row.MigrationType,
+			})
+			delete(applied, row.MigrationType)
+		}
+	}
+	return states, nil
+}
+
+func MigrationTransaction(migration MigrationTx) Migration {
+	return migrationTransaction{migration: migration}
+}
+
+type migrationTransaction struct {
+	migration MigrationTx
+}
+
+func (m migrationTransaction) Down(db *sql.DB) error {
+	return m.run(context.Background(), db, m.migration.Down)
+}
+
+func (m migrationTransaction) ID() string {
+	if identifier, ok := m.migration.(MigrationIdentifier); ok {
+		return identifier.ID()
+	}
+	return reflect.TypeOf(m.migration).String()
+}
+
+func (m migrationTransaction) run(ctx context.Context, db *sql.DB, fn func(context.Context, *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(ctx, tx); err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+	return tx.Commit()
+}
+
+func (m migrationTransaction) Up(db *sql.DB) error {
+	return m.run(context.Background(), db, m.migration.Up)
+}
+
+func migrate(ctx context.Context, db *sql.DB, migrations []Migration, targetIndex func(latestIndex int) int, migrateConfig []MigrateConfig) (logs []string, err error) {
+	var config MigrateConfig
+	if len(migrateConfig) > 0 {
+		config = migrateConfig[0]
+	}
+
+	migrationLogs := Use[MigrationLogs]().Query()
+	migrationLogs.detectDialect()
+
+	// Dry runs read the migration logs from the database and record everything else.
+	exec := db
+	var recorder *migrationRecorder
+	if config.DryRun {
+		recorder = newMigrationRecorder(migrationLogs.dialect)
+		defer recorder.db.Close()
+		exec = recorder.db
+		defer func() {
+			logs = recorder.script()
+		}()
+	} else if locker, ok := migrationLogs.dialect.(MigrationLocker); ok {
+		// Concurrent deploys wait for each other before reading the migration logs.
+		var unlock func() error
+		unlock, err = locker.LockMigrations(ctx, db, config.LockTimeout)
 		if err != nil {
-			return logs, errors.Join(fmt.Errorf("migration %s: failed to insert migration logs", migrationType), err)
+			return nil, errors.Join(errors.New("rem: migrations setup: failed to acquire migration lock"), err)
+		}
+		defer func() {
+			if unlockErr := unlock(); unlockErr != nil {
+				err = errors.Join(err, errors.New("rem: migrations: failed to release migration lock"), unlockErr)
+			}
+		}()
+	}
+
+	logs, latestIndex, err := migrateSetup(ctx, db, exec, migrations)
+	if err != nil {
+		return logs, err
+	}
+	target := targetIndex(latestIndex)
+	ids := make([]string, len(migrations))
+	for i, migration := range migrations {
+		ids[i] = MigrationId(migration)
+	}
+	log := func(message string) {
+		if recorder != nil {
+			recorder.comment(message)
+		} else {
+			logs = append(logs, message)
+		}
+	}
+
+	for i := latestIndex + 1; i <= target; i++ {
+		log("Migrating up to " + ids[i] + "...")
+		if err := migrateOne(ctx, exec, migrations[i], ids[:i+1], "up"); err != nil {
+			return logs, err
+		}
+	}
+
+	for i := latestIndex; i > target; i-- {
+		log("Migrating down to " + ids[i] + "...")
+		if err := migrateOne(ctx, exec, migrations[i], ids[:i+1], "down"); err != nil {
+			return logs, err
 		}
 	}
 
 	return logs, nil
 }
 
-func migrateSetup(db *sql.DB, migrations []Migration) ([]string, int, error) {
+func migrateOne(ctx context.Context, db *sql.DB, migration Migration, ids []string, direction string) error {
+	id := ids[len(ids)-1]
+	migrationLogs := Use[MigrationLogs]().Context(ctx)
+	migrationLogs.detectDialect()
+	row := &MigrationLogs{
+		Checksum:      migrationChecksum(ids),
+		CreatedAt:     time.Now(),
+		Direction:     direction,
+		MigrationType: id,
+	}
+
+	if txMigration, ok := migration.(migrationTransaction); ok {
+		fn := txMigration.migration.Up
+		if direction == "down" {
+			fn = txMigration.migration.Down
+		}
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return errors.Join(fmt.Errorf("rem: migration %s: failed to begin transaction", id), err)
+		}
+		if err := fn(ctx, tx); err != nil {
+			return errors.Join(fmt.Errorf("rem: migration %s: failed", id), err, tx.Rollback())
+		}
+		if ddl, ok := migrationLogs.dialect.(TransactionalDDLSupporter); ok && ddl.SupportsTransactionalDDL() {
+			// The log is committed together with the schema changes.
+			if _, err := migrationLogs.Transaction(tx).Insert(db, row); err != nil {
+				return errors.Join(fmt.Errorf("rem: migration %s: failed to insert migration logs", id), err, tx.Rollback())
+			}
+			if err := tx.Commit(); err != nil {
+				return errors.Join(fmt.Errorf("rem: migration %s: failed to commit transaction", id), err)
+			}
+			return nil
+		}
+		if err := tx.Commit(); err != nil {
+			return errors.Join(fmt.Errorf("rem: migration %s: failed to commit transaction", id), err)
+		}
+	} else {
+		var err error
+		if direction == "down" {
+			err = migration.Down(db)
+		} else {
+			err = migration.Up(db)
+		}
+		if err != nil {
+			return errors.Join(fmt.Errorf("rem: migration %s: failed", id), err)
+		}
+	}
+
+	if _, err := migrationLogs.Insert(db, row); err != nil {
+		return errors.Join(fmt.Errorf("rem: migration %s: failed to insert migration logs", id), err)
+	}
+	return nil
+}
+
+func migrateSetup(ctx context.Context, db *sql.DB, exec *sql.DB, migrations []Migration) ([]string, int, error) {
 	logs := make([]string, 0)
-	migrationLogs := Use[MigrationLogs]()
 
-	_, err := migrationLogs.TableCreate(db, TableCreateConfig{IfNotExists: true})
+	_, err := Use[MigrationLogs]().Context(ctx).TableCreate(exec, TableCreateConfig{IfNotExists: true})
 	if err != nil {
 		return nil, -1, errors.Join(errors.New("rem: migrations setup: failed to create table for migration logs"), err)
 	}
 
-	latest, err := migrationLogs.Sort("-id").First(db)
-	latestIndex := -1
+	rows, err := migrationLogRows(ctx, db, exec)
 	if err != nil {
-		if !errors.Is(err, sql.ErrNoRows) {
-			return nil, -1, errors.Join(errors.New("rem: migrations setup: failed to get migrations list"), err)
+		return nil, -1, errors.Join(errors.New("rem: migrations setup: failed to get migrations list"), err)
+	}
+
+	applied := migrationApplied(rows)
+
+	ids := make([]string, len(migrations))
+	for i, migration := range migrations {
+		ids[i] = MigrationId(migration)
+	}
+	latestIndex := -1
+	for id := range applied {
+		i := slices.Index(ids, id)
+		if i == -1 {
+			return nil, -1, fmt.Errorf("rem: migrations setup: applied migration '%s' is unknown. Use rem.MigrationRelabel to relabel migrations that were renamed", id)
 		}
-	} else {
-		for i, migration := range migrations {
-			if latest.MigrationType == reflect.TypeOf(migration).String() {
-				if latest.Direction == "down" {
-					latestIndex = i - 1
-				} else {
-					latestIndex = i
-				}
-				break
-			}
+		if i > latestIndex {
+			latestIndex = i
+		}
+	}
+	for i := 0; i <= latestIndex; i++ {
+		row, ok := applied[ids[i]]
+		if !ok {
+			return nil, -1, fmt.Errorf("rem: migrations setup: migration '%s' is missing from the applied history, but later migrations have been applied", ids[i])
+		}
+		// Logs recorded before checksums were introduced have none.
+		if row.Checksum != "" && row.Checksum != migrationChecksum(ids[:i+1]) {
+			return nil, -1, fmt.Errorf("rem: migrations setup: migration '%s' does not match the checksum of the applied history. Migrations may have been reordered", ids[i])
 		}
 	}
 
 	return logs, latestIndex, nil
 }
 
-func MigrateUp(db *sql.DB, migrations []Migration) ([]string, error) {
-	logs, latestIndex, err := migrateSetup(db, migrations)
-	if err != nil {
-		return logs, err
+func migrationApplied(rows []*MigrationLogs) map[string]*MigrationLogs {
+	// The latest log of each migration determines whether it is applied.
+	applied := make(map[string]*MigrationLogs)
+	for _, row := range rows {
+		if row.Direction == "up" {
+			applied[row.MigrationType] = row
+		} else {
+			delete(applied, row.MigrationType)
+		}
 	}
-	migrationLogs := Use[MigrationLogs]()
+	return applied
+}
 
-	for i := latestIndex + 1; i < len(migrations); i++ {
-		migrationType := reflect.TypeOf(migrations[i]).String()
-		logs = append(logs, "Migrating up to "+migrationType+"...")
-		if err := migrations[i].Up(db); err != nil {
-			return logs, errors.Join(fmt.Errorf("rem: migration %s: failed", migrationType), err)
-		}
-		_, err := migrationLogs.Insert(db, &MigrationLogs{
-			CreatedAt:     time.Now(),
-			Direction:     "up",
-			MigrationType: migrationType,
-		})
-		if err != nil {
-			return logs, errors.Join(fmt.Errorf("rem: migration %s: failed to insert migration logs", migrationType), err)
+func migrationChecksum(ids []string) string {
+	// Each checksum covers the migration and every migration before it.
+	hash := sha256.New()
+	for _, id := range ids {
+		hash.Write([]byte(id))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func migrationLogRows(ctx context.Context, db *sql.DB, exec *sql.DB) ([]*MigrationLogs, error) {
+	if exec != db {
+		// Dry runs don't create the table, so it may not exist yet.
+		migrationLogs := Use[MigrationLogs]().Context(ctx)
+		migrationLogs.detectDialect()
+		if _, ok := migrationLogs.dialect.(Introspector); ok {
+			if _, err := migrationLogs.TableIntrospect(db); errors.Is(err, ErrTableNotExist) {
+				return make([]*MigrationLogs, 0), nil
+			}
 		}
 	}
+	cursor, err := Use[MigrationLogs]().Context(ctx).Sort("id").Iter(db)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := cursor.query.Rows.Columns()
+	if err != nil {
+		cursor.Close()
+		return nil, err
+	}
+	rows := make([]*MigrationLogs, 0)
+	for cursor.Next() {
+		rows = append(rows, cursor.Row())
+	}
+	if err := cursor.Err(); err != nil {
+		cursor.Close()
+		return nil, err
+	}
+	if err := cursor.Close(); err != nil {
+		return nil, err
+	}
 
-	return logs, nil
+	// Tables created before checksums were introduced need the column.
+	if !slices.Contains(columns, "checksum") {
+		if _, err := Use[MigrationLogs]().Context(ctx).TableColumnAdd(exec, "checksum"); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
 }
@@ -0,0 +1,216 @@
+package rem
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type migrationRecorder struct {
+	db      *sql.DB
+	dialect Dialect
+	lines   []string
+	mutex   sync.Mutex
+}
+
+func newMigrationRecorder(dialect Dialect) *migrationRecorder {
+	recorder := &migrationRecorder{
+		dialect: dialect,
+		lines:   make([]string, 0),
+	}
+	recorder.db = sql.OpenDB(recorder)
+	// One connection keeps statements in the order they were executed.
+	recorder.db.SetMaxOpenConns(1)
+	return recorder
+}
+
+func (recorder *migrationRecorder) comment(message string) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.lines = append(recorder.lines, "-- "+message)
+}
+
+func (recorder *migrationRecorder) Connect(ctx context.Context) (driver.Conn, error) {
+	return &migrationRecorderConn{recorder: recorder}, nil
+}
+
+func (recorder *migrationRecorder) Driver() driver.Driver {
+	return migrationRecorderDriver{recorder: recorder}
+}
+
+func (recorder *migrationRecorder) record(query string, args []driver.NamedValue) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.lines = append(recorder.lines, migrationScriptStatement(recorder.dialect, query, args)+";")
+}
+
+func (recorder *migrationRecorder) script() []string {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	return recorder.lines
+}
+
+type migrationRecorderConn struct {
+	recorder *migrationRecorder
+}
+
+func (conn *migrationRecorderConn) Begin() (driver.Tx, error) {
+	return conn.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (conn *migrationRecorderConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	conn.recorder.record("BEGIN", nil)
+	return migrationRecorderTx{recorder: conn.recorder}, nil
+}
+
+func (conn *migrationRecorderConn) Close() error {
+	return nil
+}
+
+func (conn *migrationRecorderConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	conn.recorder.record(query, args)
+	return driver.RowsAffected(0), nil
+}
+
+func (conn *migrationRecorderConn) Prepare(query string) (driver.Stmt, error) {
+	return migrationRecorderStmt{query: query, recorder: conn.recorder}, nil
+}
+
+func (conn *migrationRecorderConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	// Queries are recorded for statements such as INSERT ... RETURNING and return no rows.
+	conn.recorder.record(query, args)
+	return migrationRecorderRows{}, nil
+}
+
+type migrationRecorderDriver struct {
+	recorder *migrationRecorder
+}
+
+func (d migrationRecorderDriver) Open(name string) (driver.Conn, error) {
+	return &migrationRecorderConn{recorder: d.recorder}, nil
+}
+
+type migrationRecorderRows struct{}
+
+func (rows migrationRecorderRows) Close() error {
+	return nil
+}
+
+func (rows migrationRecorderRows) Columns() []string {
+	return []string{}
+}
+
+func (rows migrationRecorderRows) Next(dest []driver.Value) error {
+	return io.EOF
+}
+
+type migrationRecorderStmt struct {
+	query    string
+	recorder *migrationRecorder
+}
+
+func (stmt migrationRecorderStmt) Close() error {
+	return nil
+}
+
+func (stmt migrationRecorderStmt) Exec(args []driver.Value) (driver.Result, error) {
+	stmt.recorder.record(stmt.query, migrationRecorderNamedValues(args))
+	return driver.RowsAffected(0), nil
+}
+
+func (stmt migrationRecorderStmt) NumInput() int {
+	return -1
+}
+
+func (stmt migrationRecorderStmt) Query(args []driver.Value) (driver.Rows, error) {
+	stmt.recorder.record(stmt.query, migrationRecorderNamedValues(args))
+	return migrationRecorderRows{}, nil
+}
+
+type migrationRecorderTx struct {
+	recorder *migrationRecorder
+}
+
+func (tx migrationRecorderTx) Commit() error {
+	tx.recorder.record("COMMIT", nil)
+	return nil
+}
+
+func (tx migrationRecorderTx) Rollback() error {
+	tx.recorder.record("ROLLBACK", nil)
+	return nil
+}
+
+func migrationRecorderNamedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return named
+}
+
+func migrationScriptLiteral(value driver.Value) string {
+	switch value := value.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if value {
+			return "TRUE"
+		}
+		return "FALSE"
+	case float64:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case []byte:
+		return migrationScriptLiteral(string(value))
+	case string:
+		return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	case time.Time:
+		return migrationScriptLiteral(value.UTC().Format("2006-01-02 15:04:05.999999"))
+	}
+	return "NULL"
+}
+
+func migrationScriptStatement(dialect Dialect, query string, args []driver.NamedValue) string {
+	if len(args) == 0 {
+		return query
+	}
+
+	// Placeholders are replaced with literals outside of quoted strings and identifiers.
+	var out strings.Builder
+	next := 0
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			out.WriteByte(c)
+			continue
+		}
+		if c == '\'' || c == '"' || c == '`' {
+			quote = c
+			out.WriteByte(c)
+			continue
+		}
+		if next < len(args) {
+			param := dialect.Param(next + 1)
+			end := i + len(param)
+			if strings.HasPrefix(query[i:], param) && (param == "?" || end == len(query) || query[end] < '0' || query[end] > '9') {
+				out.WriteString(migrationScriptLiteral(args[next].Value))
+				next++
+				i = end - 1
+				continue
+			}
+		}
+		out.WriteByte(c)
+	}
+	return out.String()
+}
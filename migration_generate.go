@@ -0,0 +1,396 @@
+package rem
+
+import (
+	"errors"
+	"fmt"
+	"go/format"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/exp/slices"
+)
+
+var ErrNoSchemaChanges = errors.New("rem: no schema changes")
+
+type MigrationGenerateConfig struct {
+	Name     string
+	Package  string
+	Snapshot *SchemaSnapshot
+}
+
+type MigrationModel interface {
+	SchemaChecker
+	SchemaDiff(schema *TableSchema) (*TableDiff, error)
+	TableIntrospect(db Executor) (*TableSchema, error)
+	TableSchema() (*TableSchema, error)
+	modelType() reflect.Type
+}
+
+type migrationTable struct {
+	diff       *TableDiff
+	extra      []ColumnSchema
+	mismatched []ColumnSchema
+	modelType  reflect.Type
+	name       string
+	schema     *TableSchema
+	use        string
+}
+
+func GenerateMigration(db Executor, config MigrationGenerateConfig, models ...MigrationModel) (string, error) {
+	if config.Name == "" {
+		return "", errors.New("rem: migration name is required")
+	}
+	if config.Package == "" {
+		config.Package = "migrations"
+	}
+
+	tables := make([]*migrationTable, 0)
+	unsupported := make([]string, 0)
+	for _, model := range models {
+		expected, err := model.TableSchema()
+		if err != nil {
+			return "", err
+		}
+		var actual *TableSchema
+		if config.Snapshot != nil {
+			actual, _ = config.Snapshot.Table(expected.Name)
+		} else {
+			actual, err = model.TableIntrospect(db)
+			if errors.Is(err, ErrTableNotExist) {
+				actual = nil
+			} else if err != nil {
+				return "", err
+			}
+		}
+		diff, err := model.SchemaDiff(actual)
+		if err != nil {
+			return "", err
+		}
+		if diff.Empty() {
+			continue
+		}
+
+		table := &migrationTable{
+			diff:       diff,
+			extra:      make([]ColumnSchema, 0, len(diff.ExtraColumns)),
+			mismatched: make([]ColumnSchema, 0, len(diff.ColumnMismatches)),
+			modelType:  model.modelType(),
+			schema:     expected,
+		}
+		for _, column := range diff.ExtraColumns {
+			extra, _ := actual.Column(column)
+			table.extra = append(table.extra, extra)
+		}
+		for _, mismatch := range diff.ColumnMismatches {
+			previous, _ := actual.Column(mismatch.Column)
+			table.mismatched = append(table.mismatched, previous)
+		}
+		for _, foreignKey := range diff.MissingForeignKeys {
+			if len(foreignKey.Columns) == 1 && slices.Contains(diff.MissingColumns, foreignKey.Columns[0]) {
+				// Added columns declare their own REFERENCES.
+				continue
+			}
+			unsupported = append(unsupported, fmt.Sprintf("add foreign key (%s) on table '%s' references '%s' (%s)", strings.Join(foreignKey.Columns, ", "), expected.Name, foreignKey.ReferencedTable, strings.Join(foreignKey.ReferencedColumns, ", ")))
+		}
+		tables = append(tables, table)
+	}
+	if len(unsupported) > 0 {
+		return "", fmt.Errorf("rem: migration can't express schema changes: %s", strings.Join(unsupported, "; "))
+	}
+	if len(tables) == 0 {
+		return "", ErrNoSchemaChanges
+	}
+
+	tables, err := migrationTablesSorted(tables)
+	if err != nil {
+		return "", err
+	}
+	names := make(map[string]bool)
+	for _, table := range tables {
+		table.name = table.modelType.Name()
+		if table.name == "" || strings.Contains(table.name, "[") || names[table.name] {
+			table.name = migrationIdentifier(table.schema.Name)
+		}
+		names[table.name] = true
+		table.use = fmt.Sprintf("rem.Use[%s]()", table.name)
+		if strings.ToLower(table.name) != table.schema.Name {
+			table.use = fmt.Sprintf("rem.Use[%s](rem.Config{Table: %q})", table.name, table.schema.Name)
+		}
+	}
+
+	imports := map[string]bool{
+		"database/sql": true,
+		remPackagePath: true,
+	}
+	declarations := migrationDeclarations(tables, imports)
+	downDeclarations := declarations + migrationPreviousDeclarations(tables, imports)
+
+	var up strings.Builder
+	for _, table := range tables {
+		if table.diff.Missing {
+			if len(table.schema.Indexes) > 0 {
+				migrationStatement(&up, table.use, "TableCreate(db, rem.TableCreateConfig{Indexes: true})")
+			} else {
+				migrationStatement(&up, table.use, "TableCreate(db)")
+			}
+			continue
+		}
+		for _, column := range table.diff.MissingColumns {
+			migrationStatement(&up, table.use, fmt.Sprintf("TableColumnAdd(db, %q)", column))
+		}
+		// Columns are altered while the table matches the declared model, which SQLite rebuilds the table from.
+		for _, mismatch := range table.diff.ColumnMismatches {
+			migrationStatement(&up, table.use, fmt.Sprintf("TableColumnAlter(db, %q)", mismatch.Column))
+		}
+		for _, column := range table.diff.ExtraColumns {
+			migrationStatement(&up, table.use, fmt.Sprintf("TableColumnDrop(db, %q)", column))
+		}
+		for _, index := range table.diff.MissingIndexes {
+			migrationStatement(&up, table.use, fmt.Sprintf("TableIndexCreate(db, %q)", index.Name))
+		}
+	}
+
+	var down strings.Builder
+	for i := len(tables) - 1; i > -1; i-- {
+		table := tables[i]
+		if table.diff.Missing {
+			migrationStatement(&down, table.use, "TableDrop(db)")
+			continue
+		}
+		for j := len(table.diff.MissingIndexes) - 1; j > -1; j-- {
+			migrationStatement(&down, table.use, fmt.Sprintf("TableIndexDrop(db, %q)", table.diff.MissingIndexes[j].Name))
+		}
+		for j := len(table.diff.ExtraColumns) - 1; j > -1; j-- {
+			migrationStatement(&down, table.use, fmt.Sprintf("TableColumnAdd(db, %q)", table.diff.ExtraColumns[j]))
+		}
+		for j := len(table.diff.MissingColumns) - 1; j > -1; j-- {
+			migrationStatement(&down, table.use, fmt.Sprintf("TableColumnDrop(db, %q)", table.diff.MissingColumns[j]))
+		}
+		for _, mismatch := range table.diff.ColumnMismatches {
+			migrationStatement(&down, fmt.Sprintf("rem.Use[%sPrevious](rem.Config{Table: %q})", table.name, table.schema.Name), fmt.Sprintf("TableColumnAlter(db, %q)", mismatch.Column))
+		}
+	}
+
+	importPaths := make([]string, 0, len(imports))
+	for path := range imports {
+		importPaths = append(importPaths, path)
+	}
+	sort.Strings(importPaths)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "package %s\n\nimport (\n", config.Package)
+	for _, path := range importPaths {
+		if !strings.Contains(strings.Split(path, "/")[0], ".") {
+			fmt.Fprintf(&out, "\t%q\n", path)
+		}
+	}
+	out.WriteString("\n")
+	for _, path := range importPaths {
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			fmt.Fprintf(&out, "\t%q\n", path)
+		}
+	}
+	out.WriteString(")\n\n")
+	fmt.Fprintf(&out, "type %s struct{}\n\n", config.Name)
+	fmt.Fprintf(&out, "func (m %s) Up(db *sql.DB) error {\n%s\n%s\treturn nil\n}\n\n", config.Name, declarations, up.String())
+	fmt.Fprintf(&out, "func (m %s) Down(db *sql.DB) error {\n%s\n%s\treturn nil\n}\n", config.Name, downDeclarations, down.String())
+
+	source, err := format.Source([]byte(out.String()))
+	if err != nil {
+		return "", errors.Join(errors.New("rem: failed to format generated migration"), err)
+	}
+	return string(source), nil
+}
+
+var remPackagePath = reflect.TypeOf(Config{}).PkgPath()
+
+func migrationDeclarations(tables []*migrationTable, imports map[string]bool) string {
+	var out strings.Builder
+
+	// Related models that aren't part of the migration only need their primary keys.
+	declared := make(map[reflect.Type]bool, len(tables))
+	for _, table := range tables {
+		declared[table.modelType] = true
+	}
+	related := make(map[string]reflect.Type)
+	for _, table := range tables {
+		for _, field := range migrationStructFields(table.modelType) {
+			if relation, ok := reflect.New(field.Type).Interface().(relationField); ok && !declared[relation.relatedType()] {
+				related[relation.relatedType().Name()] = relation.relatedType()
+			}
+		}
+	}
+	relatedNames := make([]string, 0, len(related))
+	for name := range related {
+		relatedNames = append(relatedNames, name)
+	}
+	sort.Strings(relatedNames)
+	for _, name := range relatedNames {
+		fmt.Fprintf(&out, "\ttype %s struct {\n", name)
+		for _, field := range primaryStructFields(related[name]) {
+			fmt.Fprintf(&out, "\t\t%s %s `%s`\n", field.Name, migrationGoType(field.Type, imports), field.Tag)
+		}
+		out.WriteString("\t}\n\n")
+	}
+
+	for _, table := range tables {
+		fmt.Fprintf(&out, "\ttype %s struct {\n", table.name)
+		names := make(map[string]bool)
+		for _, field := range migrationStructFields(table.modelType) {
+			names[field.Name] = true
+			fmt.Fprintf(&out, "\t\t%s %s `%s`\n", field.Name, migrationGoType(field.Type, imports), field.Tag)
+		}
+		for _, column := range table.extra {
+			migrationColumnField(&out, names, column)
+		}
+		out.WriteString("\t}\n\n")
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+func migrationColumnField(out *strings.Builder, names map[string]bool, column ColumnSchema) {
+	name := migrationIdentifier(column.Name)
+	for i := 2; names[name]; i++ {
+		name = fmt.Sprint(migrationIdentifier(column.Name), i)
+	}
+	names[name] = true
+	columnType := columnDescription(column.Type, column.Nullable)
+	if column.Default.Valid {
+		columnType += " DEFAULT " + column.Default.String
+	}
+	fmt.Fprintf(out, "\t\t%s string `db:%q db_type:%q`\n", name, column.Name, columnType)
+}
+
+func migrationGoType(fieldType reflect.Type, imports map[string]bool) string {
+	if relation, ok := reflect.New(fieldType).Interface().(relationField); ok && fieldType.PkgPath() == remPackagePath {
+		base, _, _ := strings.Cut(fieldType.Name(), "[")
+		return fmt.Sprintf("rem.%s[%s]", base, relation.relatedType().Name())
+	}
+	if fieldType.Name() != "" {
+		if fieldType.PkgPath() != "" {
+			imports[fieldType.PkgPath()] = true
+		}
+		return fieldType.String()
+	}
+	switch fieldType.Kind() {
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", fieldType.Len(), migrationGoType(fieldType.Elem(), imports))
+	case reflect.Map:
+		return fmt.Sprintf("map[%s]%s", migrationGoType(fieldType.Key(), imports), migrationGoType(fieldType.Elem(), imports))
+	case reflect.Pointer:
+		return "*" + migrationGoType(fieldType.Elem(), imports)
+	case reflect.Slice:
+		return "[]" + migrationGoType(fieldType.Elem(), imports)
+	}
+	return fieldType.String()
+}
+
+func migrationIdentifier(name string) string {
+	var out strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		out.WriteRune(r)
+	}
+	identifier := out.String()
+	if identifier == "" || unicode.IsDigit([]rune(identifier)[0]) {
+		identifier = "T" + identifier
+	}
+	return identifier
+}
+
+func migrationPreviousDeclarations(tables []*migrationTable, imports map[string]bool) string {
+	var out strings.Builder
+
+	// Altered columns are reverted with a model of the table as it was before the migration.
+	for _, table := range tables {
+		if len(table.mismatched) == 0 {
+			continue
+		}
+		previous := make(map[string]ColumnSchema, len(table.mismatched))
+		for _, column := range table.mismatched {
+			previous[column.Name] = column
+		}
+		fmt.Fprintf(&out, "\n\ttype %sPrevious struct {\n", table.name)
+		names := make(map[string]bool)
+		for _, field := range migrationStructFields(table.modelType) {
+			column := field.Tag.Get("db")
+			if slices.Contains(table.diff.MissingColumns, column) {
+				continue
+			}
+			if previousColumn, ok := previous[column]; ok {
+				migrationColumnField(&out, names, previousColumn)
+				continue
+			}
+			names[field.Name] = true
+			fmt.Fprintf(&out, "\t\t%s %s `%s`\n", field.Name, migrationGoType(field.Type, imports), field.Tag)
+		}
+		for _, column := range table.extra {
+			migrationColumnField(&out, names, column)
+		}
+		out.WriteString("\t}\n")
+	}
+	return out.String()
+}
+
+func migrationStatement(out *strings.Builder, use string, call string) {
+	fmt.Fprintf(out, "\tif _, err := %s.%s; err != nil {\n\t\treturn err\n\t}\n", use, call)
+}
+
+func migrationStructFields(modelType reflect.Type) []reflect.StructField {
+	fields := make([]reflect.StructField, 0)
+	for _, field := range reflect.VisibleFields(modelType) {
+		if _, ok := field.Tag.Lookup("db"); !ok || field.Anonymous {
+			continue
+		}
+		if relation, ok := reflect.New(field.Type).Interface().(relationField); ok && relation.relationKind() == fieldOneToMany {
+			// One-to-many relations don't have columns on this table.
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func migrationTablesSorted(tables []*migrationTable) ([]*migrationTable, error) {
+	// Tables are ordered so that referenced tables are created first.
+	sorted := make([]*migrationTable, 0, len(tables))
+	visited := make(map[*migrationTable]int, len(tables))
+	var visit func(table *migrationTable) error
+	visit = func(table *migrationTable) error {
+		switch visited[table] {
+		case 1:
+			return fmt.Errorf("rem: circular foreign keys on table '%s'", table.schema.Name)
+		case 2:
+			return nil
+		}
+		visited[table] = 1
+		for _, foreignKey := range table.schema.ForeignKeys {
+			for _, other := range tables {
+				if other != table && other.schema.Name == foreignKey.ReferencedTable {
+					if err := visit(other); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		visited[table] = 2
+		sorted = append(sorted, table)
+		return nil
+	}
+	for _, table := range tables {
+		if err := visit(table); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
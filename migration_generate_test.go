@@ -0,0 +1,163 @@
+package rem
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type testGroupsGenerate struct {
+	Id   int64  `db:"id" db_primary:"true" db_type:"BIGSERIAL PRIMARY KEY NOT NULL"`
+	Name string `db:"name" db_type:"TEXT NOT NULL"`
+}
+
+type testAccountsGenerate struct {
+	Email sql.NullString                 `db:"email" db_index:"true" db_type:"VARCHAR(100) NULL"`
+	Group ForeignKey[testGroupsGenerate] `db:"group_id" db_type:"BIGINT NOT NULL REFERENCES testgroupsgenerate (id)"`
+	Id    int64                          `db:"id" db_primary:"true" db_type:"BIGSERIAL PRIMARY KEY NOT NULL"`
+	Name  string                         `db:"name" db_type:"VARCHAR(100) NOT NULL"`
+	Junk  string
+}
+
+func TestGenerateMigration(t *testing.T) {
+	defer func() {
+		defaultDialect = nil
+	}()
+	SetDialect(testIntrospectorDialect{
+		schemas: map[string]*TableSchema{
+			"testaccountsgenerate": {
+				Columns: []ColumnSchema{
+					{AutoIncrement: true, Name: "id", Type: "BIGINT"},
+					{Name: "name", Type: "VARCHAR(50)"},
+					{Default: sql.NullString{String: "'x'", Valid: true}, Name: "legacy", Nullable: true, Type: "TEXT"},
+				},
+				Name: "testaccountsgenerate",
+			},
+		},
+	})
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	source, err := GenerateMigration(db, MigrationGenerateConfig{Name: "Migration0002Accounts"}, Use[testAccountsGenerate](), Use[testGroupsGenerate]())
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	expected := []string{
+		"package migrations\n",
+		"\"database/sql\"\n\n\t\"github.com/evantbyrne/rem\"\n",
+		"type Migration0002Accounts struct{}\n",
+		"func (m Migration0002Accounts) Up(db *sql.DB) error {\n",
+		"\ttype testGroupsGenerate struct {\n",
+		"\ttype testAccountsGenerate struct {\n",
+		"\t\tGroup  rem.ForeignKey[testGroupsGenerate] `db:\"group_id\" db_type:\"BIGINT NOT NULL REFERENCES testgroupsgenerate (id)\"`\n",
+		"\t\tLegacy string                             `db:\"legacy\" db_type:\"TEXT NULL DEFAULT 'x'\"`\n",
+		"rem.Use[testGroupsGenerate]().TableCreate(db); err != nil {\n",
+		"rem.Use[testAccountsGenerate]().TableColumnAdd(db, \"email\"); err != nil {\n",
+		"rem.Use[testAccountsGenerate]().TableColumnAdd(db, \"group_id\"); err != nil {\n",
+		"rem.Use[testAccountsGenerate]().TableColumnAlter(db, \"name\"); err != nil {\n",
+		"rem.Use[testAccountsGenerate]().TableColumnDrop(db, \"legacy\"); err != nil {\n",
+		"rem.Use[testAccountsGenerate]().TableIndexCreate(db, \"testaccountsgenerate_email_idx\"); err != nil {\n",
+		"func (m Migration0002Accounts) Down(db *sql.DB) error {\n",
+		"\ttype testAccountsGeneratePrevious struct {\n",
+		"\t\tId     int64  `db:\"id\" db_primary:\"true\" db_type:\"BIGSERIAL PRIMARY KEY NOT NULL\"`\n",
+		"\t\tName   string `db:\"name\" db_type:\"VARCHAR(50) NOT NULL\"`\n",
+		"\t\tLegacy string `db:\"legacy\" db_type:\"TEXT NULL DEFAULT 'x'\"`\n",
+		"rem.Use[testAccountsGenerate]().TableIndexDrop(db, \"testaccountsgenerate_email_idx\"); err != nil {\n",
+		"rem.Use[testAccountsGenerate]().TableColumnAdd(db, \"legacy\"); err != nil {\n",
+		"rem.Use[testAccountsGenerate]().TableColumnDrop(db, \"group_id\"); err != nil {\n",
+		"rem.Use[testAccountsGenerate]().TableColumnDrop(db, \"email\"); err != nil {\n",
+		"rem.Use[testAccountsGeneratePrevious](rem.Config{Table: \"testaccountsgenerate\"}).TableColumnAlter(db, \"name\"); err != nil {\n",
+		"rem.Use[testGroupsGenerate]().TableDrop(db); err != nil {\n",
+	}
+	offset := 0
+	for _, snippet := range expected {
+		i := strings.Index(source[offset:], snippet)
+		if i < 0 {
+			t.Fatalf("Expected '%s' after offset %d, got:\n%s", snippet, offset, source)
+		}
+		offset += i + len(snippet)
+	}
+
+	// Foreign keys can't be added to existing columns.
+	SetDialect(testIntrospectorDialect{
+		schemas: map[string]*TableSchema{
+			"testaccountsgenerate": {
+				Columns: []ColumnSchema{
+					{Name: "email", Nullable: true, Type: "VARCHAR(100)"},
+					{Name: "group_id", Type: "BIGINT"},
+					{AutoIncrement: true, Name: "id", Type: "BIGINT"},
+					{Name: "name", Type: "VARCHAR(100)"},
+				},
+				Indexes: []Index{{Columns: []string{"email"}, Name: "testaccountsgenerate_email_idx"}},
+				Name:    "testaccountsgenerate",
+			},
+		},
+	})
+	_, err = GenerateMigration(db, MigrationGenerateConfig{Name: "Migration0003Accounts"}, Use[testAccountsGenerate]())
+	expectedErr := "rem: migration can't express schema changes: add foreign key (group_id) on table 'testaccountsgenerate' references 'testgroupsgenerate' (id)"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("Expected '%s', got '%v'", expectedErr, err)
+	}
+}
+
+func TestGenerateMigrationSnapshot(t *testing.T) {
+	defer func() {
+		defaultDialect = nil
+	}()
+	SetDialect(testIntrospectorDialect{})
+
+	snapshot, err := Snapshot(Use[testGroupsGenerate]())
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	expectedTable := &TableSchema{
+		Columns: []ColumnSchema{
+			{AutoIncrement: true, Name: "id", Type: "BIGSERIAL"},
+			{Name: "name", Type: "TEXT"},
+		},
+		ForeignKeys: []ForeignKeySchema{},
+		Indexes:     []Index{},
+		Name:        "testgroupsgenerate",
+		PrimaryKey:  []string{"id"},
+	}
+	if table, ok := snapshot.Table("testgroupsgenerate"); !ok || !reflect.DeepEqual(table, expectedTable) {
+		t.Errorf("Expected '%+v', got '%+v'", expectedTable, table)
+	}
+
+	// Snapshots don't need a database connection.
+	source, err := GenerateMigration(nil, MigrationGenerateConfig{Name: "Migration0002", Package: "db", Snapshot: snapshot}, Use[testAccountsGenerate](), Use[testGroupsGenerate]())
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if !strings.HasPrefix(source, "package db\n") {
+		t.Errorf("Expected package 'db', got:\n%s", source)
+	}
+	if !strings.Contains(source, "rem.Use[testAccountsGenerate]().TableCreate(db, rem.TableCreateConfig{Indexes: true}); err != nil {") {
+		t.Errorf("Expected accounts table create, got:\n%s", source)
+	}
+	if strings.Contains(source, "rem.Use[testGroupsGenerate]()") {
+		t.Errorf("Expected no changes to groups table, got:\n%s", source)
+	}
+
+	snapshot, err = Snapshot(Use[testAccountsGenerate](), Use[testGroupsGenerate]())
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	_, err = GenerateMigration(nil, MigrationGenerateConfig{Name: "Migration0003", Snapshot: snapshot}, Use[testAccountsGenerate](), Use[testGroupsGenerate]())
+	if !errors.Is(err, ErrNoSchemaChanges) {
+		t.Errorf("Expected ErrNoSchemaChanges, got '%v'", err)
+	}
+
+	_, err = GenerateMigration(nil, MigrationGenerateConfig{Snapshot: snapshot}, Use[testGroupsGenerate]())
+	if err == nil || err.Error() != "rem: migration name is required" {
+		t.Errorf("Expected missing name error, got '%v'", err)
+	}
+}
@@ -0,0 +1,553 @@
+package rem
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type testMigrationA struct {
+	calls *[]string
+}
+
+func (m testMigrationA) Down(db *sql.DB) error {
+	*m.calls = append(*m.calls, "down a")
+	return nil
+}
+
+func (m testMigrationA) Up(db *sql.DB) error {
+	*m.calls = append(*m.calls, "up a")
+	return nil
+}
+
+type testMigrationB struct {
+	calls *[]string
+}
+
+func (m testMigrationB) Down(db *sql.DB) error {
+	*m.calls = append(*m.calls, "down b")
+	return nil
+}
+
+func (m testMigrationB) ID() string {
+	return "0002_b"
+}
+
+func (m testMigrationB) Up(db *sql.DB) error {
+	*m.calls = append(*m.calls, "up b")
+	return nil
+}
+
+const testMigrationLogsCreate = "CREATE|migrationlogs|checksum,created_at,direction,id,migration_type|{IfNotExists:true Indexes:false}|"
+
+func testMigrationLogRows(rows ...[]interface{}) *sqlmock.Rows {
+	result := sqlmock.NewRows([]string{"checksum", "created_at", "direction", "id", "migration_type"})
+	for _, row := range rows {
+		result.AddRow(row[0], time.Time{}, row[1], row[2], row[3])
+	}
+	return result
+}
+
+func TestMigrateUp(t *testing.T) {
+	defer func() {
+		defaultDialect = nil
+	}()
+	SetDialect(testDialect{})
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	calls := make([]string, 0)
+	migrations := []Migration{testMigrationA{calls: &calls}, testMigrationB{calls: &calls}}
+	if MigrationId(migrations[0]) != "rem.testMigrationA" || MigrationId(migrations[1]) != "0002_b" {
+		t.Errorf("Unexpected migration IDs '%s' and '%s'", MigrationId(migrations[0]), MigrationId(migrations[1]))
+	}
+
+	checksumA := migrationChecksum([]string{"rem.testMigrationA"})
+	checksumB := migrationChecksum([]string{"rem.testMigrationA", "0002_b"})
+	mock.ExpectExec(testMigrationLogsCreate).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT|FILTER[]|").WillReturnRows(testMigrationLogRows(
+		[]interface{}{checksumA, "up", 1, "rem.testMigrationA"},
+	))
+	mock.ExpectExec("INSERT|migrationlogs|checksum,created_at,direction,migration_type|RETURNING|").
+		WithArgs(checksumB, sqlmock.AnyArg(), "up", "0002_b").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+
+	logs, err := MigrateUp(db, migrations)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if strings.Join(logs, ",") != "Migrating up to 0002_b..." {
+		t.Errorf("Unexpected logs '%s'", strings.Join(logs, ","))
+	}
+	if strings.Join(calls, ",") != "up b" {
+		t.Errorf("Unexpected calls '%s'", strings.Join(calls, ","))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMigrateSetup(t *testing.T) {
+	defer func() {
+		defaultDialect = nil
+	}()
+	SetDialect(testDialect{})
+
+	calls := make([]string, 0)
+	migrations := []Migration{testMigrationA{calls: &calls}, testMigrationB{calls: &calls}}
+	checksumA := migrationChecksum([]string{"rem.testMigrationA"})
+
+	expectations := map[string]*sqlmock.Rows{
+		"rem: migrations setup: applied migration 'rem.testMigrationB' is unknown. Use rem.MigrationRelabel to relabel migrations that were renamed": testMigrationLogRows(
+			[]interface{}{checksumA, "up", 1, "rem.testMigrationA"},
+			[]interface{}{"", "up", 2, "rem.testMigrationB"},
+		),
+		"rem: migrations setup: migration 'rem.testMigrationA' is missing from the applied history, but later migrations have been applied": testMigrationLogRows(
+			[]interface{}{checksumA, "up", 1, "rem.testMigrationA"},
+			[]interface{}{"", "up", 2, "0002_b"},
+			[]interface{}{checksumA, "down", 3, "rem.testMigrationA"},
+		),
+		"rem: migrations setup: migration '0002_b' does not match the checksum of the applied history. Migrations may have been reordered": testMigrationLogRows(
+			[]interface{}{checksumA, "up", 1, "rem.testMigrationA"},
+			[]interface{}{migrationChecksum([]string{"0002_b"}), "up", 2, "0002_b"},
+		),
+	}
+	for expected, rows := range expectations {
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatal("failed to open sqlmock database:", err)
+		}
+		mock.ExpectExec(testMigrationLogsCreate).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT|FILTER[]|").WillReturnRows(rows)
+		if _, err := MigrateUp(db, migrations); err == nil || err.Error() != expected {
+			t.Errorf("Expected '%s', got '%v'", expected, err)
+		}
+		db.Close()
+	}
+	if len(calls) > 0 {
+		t.Errorf("Unexpected calls '%s'", strings.Join(calls, ","))
+	}
+
+	// Logs tables created before checksums were introduced.
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+	mock.ExpectExec(testMigrationLogsCreate).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT|FILTER[]|").WillReturnRows(sqlmock.NewRows([]string{"created_at", "direction", "id", "migration_type"}).
+		AddRow(time.Time{}, "up", 1, "rem.testMigrationA").
+		AddRow(time.Time{}, "up", 2, "0002_b"))
+	mock.ExpectExec("ADD COLUMN|migrationlogs|checksum|").WillReturnResult(sqlmock.NewResult(0, 0))
+	if _, err := MigrateUp(db, migrations); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMigrationRelabel(t *testing.T) {
+	defer func() {
+		defaultDialect = nil
+	}()
+	SetDialect(testDialect{})
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	calls := make([]string, 0)
+	migrations := []Migration{testMigrationA{calls: &calls}, testMigrationB{calls: &calls}}
+	checksumA := migrationChecksum([]string{"rem.testMigrationA"})
+	checksumB := migrationChecksum([]string{"rem.testMigrationA", "0002_b"})
+
+	mock.ExpectExec(testMigrationLogsCreate).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT|FILTER[]|").WillReturnRows(testMigrationLogRows(
+		[]interface{}{"", "up", 1, "old.testMigrationA"},
+		[]interface{}{"", "up", 2, "rem.testMigrationB"},
+		[]interface{}{"", "up", 3, "other.Removed"},
+	))
+	mock.ExpectExec("UPDATE|migrationlogs|checksum,migration_type|FILTER[{Left:id Operator:= Right:1 Rule:WHERE}]|RETURNING|").
+		WithArgs(checksumA, "rem.testMigrationA").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE|migrationlogs|checksum,migration_type|FILTER[{Left:id Operator:= Right:2 Rule:WHERE}]|RETURNING|").
+		WithArgs(checksumB, "0002_b").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	logs, err := MigrationRelabel(db, migrations, map[string]string{"old.testMigrationA": "rem.testMigrationA"})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	expected := "Relabeling migration log 1 from old.testMigrationA to rem.testMigrationA...,Relabeling migration log 2 from rem.testMigrationB to 0002_b..."
+	if strings.Join(logs, ",") != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, strings.Join(logs, ","))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+type testMigrationTx struct {
+	err error
+}
+
+func (m testMigrationTx) Down(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, "DROP TABLE accounts")
+	return err
+}
+
+func (m testMigrationTx) ID() string {
+	return "0001_tx"
+}
+
+func (m testMigrationTx) Up(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx, "CREATE TABLE accounts"); err != nil {
+		return err
+	}
+	return m.err
+}
+
+func TestMigrateUpContextTransaction(t *testing.T) {
+	defer func() {
+		defaultDialect = nil
+	}()
+	checksum := migrationChecksum([]string{"0001_tx"})
+
+	// Transactional DDL commits the log with the migration.
+	SetDialect(testDialect{})
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+	mock.ExpectExec(testMigrationLogsCreate).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT|FILTER[]|").WillReturnRows(testMigrationLogRows())
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TABLE accounts").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT|migrationlogs|checksum,created_at,direction,migration_type|RETURNING|").
+		WithArgs(checksum, sqlmock.AnyArg(), "up", "0001_tx").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	migrations := []Migration{MigrationTransaction(testMigrationTx{})}
+	if MigrationId(migrations[0]) != "0001_tx" {
+		t.Errorf("Expected ID '0001_tx', got '%s'", MigrationId(migrations[0]))
+	}
+	if _, err := MigrateUpContext(context.Background(), db, migrations); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// Failures roll back the migration and skip the log.
+	mock.ExpectExec(testMigrationLogsCreate).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT|FILTER[]|").WillReturnRows(testMigrationLogRows())
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TABLE accounts").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	migrations = []Migration{MigrationTransaction(testMigrationTx{err: errors.New("boom")})}
+	_, err = MigrateUpContext(context.Background(), db, migrations)
+	if err == nil || err.Error() != "rem: migration 0001_tx: failed\nboom" {
+		t.Errorf("Expected migration failure, got '%v'", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// Without transactional DDL the log is inserted after the commit.
+	SetDialect(testDialect{nonTransactionalDDL: true})
+	mock.ExpectExec(testMigrationLogsCreate).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT|FILTER[]|").WillReturnRows(testMigrationLogRows(
+		[]interface{}{checksum, "up", 1, "0001_tx"},
+	))
+	mock.ExpectBegin()
+	mock.ExpectExec("DROP TABLE accounts").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectExec("INSERT|migrationlogs|checksum,created_at,direction,migration_type|RETURNING|").
+		WithArgs(checksum, sqlmock.AnyArg(), "down", "0001_tx").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+
+	migrations = []Migration{MigrationTransaction(testMigrationTx{})}
+	if _, err := MigrateDownContext(context.Background(), db, migrations); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+type testLockerDialect struct {
+	testDialect
+	calls     *[]string
+	err       error
+	unlockErr error
+}
+
+func (dialect testLockerDialect) LockMigrations(ctx context.Context, db *sql.DB, timeout time.Duration) (func() error, error) {
+	*dialect.calls = append(*dialect.calls, "lock "+timeout.String())
+	if dialect.err != nil {
+		return nil, dialect.err
+	}
+	return func() error {
+		*dialect.calls = append(*dialect.calls, "unlock")
+		return dialect.unlockErr
+	}, nil
+}
+
+func TestMigrateUpLock(t *testing.T) {
+	defer func() {
+		defaultDialect = nil
+	}()
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	calls := make([]string, 0)
+	SetDialect(testLockerDialect{calls: &calls})
+	mock.ExpectExec(testMigrationLogsCreate).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT|FILTER[]|").WillReturnRows(testMigrationLogRows())
+	mock.ExpectExec("INSERT|migrationlogs|checksum,created_at,direction,migration_type|RETURNING|").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	migrations := []Migration{testMigrationA{calls: &calls}}
+	if _, err := MigrateUp(db, migrations, MigrateConfig{LockTimeout: 5 * time.Second}); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if strings.Join(calls, ",") != "lock 5s,up a,unlock" {
+		t.Errorf("Unexpected calls '%s'", strings.Join(calls, ","))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// Nothing runs when the lock is not acquired.
+	calls = make([]string, 0)
+	SetDialect(testLockerDialect{calls: &calls, err: ErrMigrationLockTimeout})
+	_, err = MigrateUp(db, migrations)
+	if !errors.Is(err, ErrMigrationLockTimeout) {
+		t.Errorf("Expected ErrMigrationLockTimeout, got '%v'", err)
+	}
+	if strings.Join(calls, ",") != "lock 0s" {
+		t.Errorf("Unexpected calls '%s'", strings.Join(calls, ","))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// Failing to release the lock is reported after the migrations have run.
+	calls = make([]string, 0)
+	unlockErr := errors.New("connection reset")
+	SetDialect(testLockerDialect{calls: &calls, unlockErr: unlockErr})
+	mock.ExpectExec(testMigrationLogsCreate).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT|FILTER[]|").WillReturnRows(testMigrationLogRows())
+	mock.ExpectExec("INSERT|migrationlogs|checksum,created_at,direction,migration_type|RETURNING|").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	logs, err := MigrateUp(db, migrations)
+	if !errors.Is(err, unlockErr) || !strings.Contains(err.Error(), "rem: migrations: failed to release migration lock") {
+		t.Errorf("Expected unlock error, got '%v'", err)
+	}
+	if strings.Join(logs, ",") != "Migrating up to rem.testMigrationA..." {
+		t.Errorf("Unexpected logs '%s'", strings.Join(logs, ","))
+	}
+	if strings.Join(calls, ",") != "lock 0s,up a,unlock" {
+		t.Errorf("Unexpected calls '%s'", strings.Join(calls, ","))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMigrateUpDryRun(t *testing.T) {
+	defer func() {
+		defaultDialect = nil
+	}()
+	SetDialect(testDialect{})
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	// Only the migration logs are read from the database.
+	mock.ExpectQuery("SELECT|FILTER[]|").WillReturnRows(testMigrationLogRows())
+
+	calls := make([]string, 0)
+	migrations := []Migration{MigrationTransaction(testMigrationTx{}), testMigrationB{calls: &calls}}
+	logs, err := MigrateUp(db, migrations, MigrateConfig{DryRun: true})
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	expected := []string{
+		testMigrationLogsCreate + ";",
+		"-- Migrating up to 0001_tx...",
+		"BEGIN;",
+		"CREATE TABLE accounts;",
+		"INSERT|migrationlogs|checksum,created_at,direction,migration_type|RETURNING|;",
+		"COMMIT;",
+		"-- Migrating up to 0002_b...",
+		"INSERT|migrationlogs|checksum,created_at,direction,migration_type|RETURNING|;",
+	}
+	if strings.Join(logs, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected script:\n%s\nGot:\n%s", strings.Join(expected, "\n"), strings.Join(logs, "\n"))
+	}
+	if strings.Join(calls, ",") != "up b" {
+		t.Errorf("Unexpected calls '%s'", strings.Join(calls, ","))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+type testQuestionParamDialect struct {
+	testDialect
+}
+
+func (dialect testQuestionParamDialect) Param(identifier int) string {
+	return "?"
+}
+
+func TestMigrationScriptStatement(t *testing.T) {
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	args := []driver.NamedValue{
+		{Ordinal: 1, Value: "it's"},
+		{Ordinal: 2, Value: createdAt},
+		{Ordinal: 3, Value: nil},
+		{Ordinal: 4, Value: int64(10)},
+		{Ordinal: 5, Value: true},
+		{Ordinal: 6, Value: 1.5},
+		{Ordinal: 7, Value: []byte("x")},
+		{Ordinal: 8, Value: int64(8)},
+		{Ordinal: 9, Value: int64(9)},
+		{Ordinal: 10, Value: int64(100)},
+	}
+	expected := `INSERT INTO "a$1" VALUES ('it''s', '2024-01-02 03:04:05', NULL, 10, TRUE, 1.5, 'x', 8, 9, 100) -- '$1'`
+	if actual := migrationScriptStatement(testDialect{}, `INSERT INTO "a$1" VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) -- '$1'`, args); actual != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, actual)
+	}
+
+	expected = "UPDATE `t?` SET `a` = 'b' WHERE `c` = 2 AND d = '?'"
+	args = []driver.NamedValue{{Ordinal: 1, Value: "b"}, {Ordinal: 2, Value: int64(2)}}
+	if actual := migrationScriptStatement(testQuestionParamDialect{}, "UPDATE `t?` SET `a` = ? WHERE `c` = ? AND d = '?'", args); actual != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, actual)
+	}
+}
+
+func TestMigrateDownN(t *testing.T) {
+	defer func() {
+		defaultDialect = nil
+	}()
+	SetDialect(testDialect{})
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	calls := make([]string, 0)
+	migrations := []Migration{testMigrationA{calls: &calls}, testMigrationB{calls: &calls}}
+	checksumA := migrationChecksum([]string{"rem.testMigrationA"})
+	checksumB := migrationChecksum([]string{"rem.testMigrationA", "0002_b"})
+	mock.ExpectExec(testMigrationLogsCreate).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT|FILTER[]|").WillReturnRows(testMigrationLogRows(
+		[]interface{}{checksumA, "up", 1, "rem.testMigrationA"},
+		[]interface{}{checksumB, "up", 2, "0002_b"},
+	))
+	mock.ExpectExec("INSERT|migrationlogs|checksum,created_at,direction,migration_type|RETURNING|").
+		WithArgs(checksumB, sqlmock.AnyArg(), "down", "0002_b").
+		WillReturnResult(sqlmock.NewResult(3, 1))
+
+	logs, err := MigrateDownN(db, migrations, 1)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if strings.Join(logs, ",") != "Migrating down to 0002_b..." {
+		t.Errorf("Unexpected logs '%s'", strings.Join(logs, ","))
+	}
+	if strings.Join(calls, ",") != "down b" {
+		t.Errorf("Unexpected calls '%s'", strings.Join(calls, ","))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// Rolling back more migrations than are applied stops at the first.
+	calls = make([]string, 0)
+	mock.ExpectExec(testMigrationLogsCreate).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT|FILTER[]|").WillReturnRows(testMigrationLogRows(
+		[]interface{}{checksumA, "up", 1, "rem.testMigrationA"},
+	))
+	mock.ExpectExec("INSERT|migrationlogs|checksum,created_at,direction,migration_type|RETURNING|").
+		WithArgs(checksumA, sqlmock.AnyArg(), "down", "rem.testMigrationA").
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	if _, err := MigrateDownN(db, migrations, 5); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if strings.Join(calls, ",") != "down a" {
+		t.Errorf("Unexpected calls '%s'", strings.Join(calls, ","))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := MigrateDownN(db, migrations, -1); err == nil || err.Error() != "rem: invalid number of migrations -1" {
+		t.Errorf("Expected invalid number error, got '%v'", err)
+	}
+}
+
+func TestMigrationStatus(t *testing.T) {
+	defer func() {
+		defaultDialect = nil
+	}()
+	SetDialect(testDialect{})
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock.ExpectExec(testMigrationLogsCreate).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT|FILTER[]|").WillReturnRows(sqlmock.NewRows([]string{"checksum", "created_at", "direction", "id", "migration_type"}).
+		AddRow("", createdAt, "up", 1, "rem.testMigrationA").
+		AddRow("", createdAt, "up", 2, "0002_b").
+		AddRow("", createdAt, "down", 3, "0002_b").
+		AddRow("", createdAt, "up", 4, "0003_removed"))
+
+	calls := make([]string, 0)
+	migrations := []Migration{testMigrationA{calls: &calls}, testMigrationB{calls: &calls}}
+	states, err := MigrationStatus(db, migrations)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	expected := []MigrationState{
+		{Applied: true, AppliedAt: createdAt, ID: "rem.testMigrationA", Migration: migrations[0]},
+		{ID: "0002_b", Migration: migrations[1]},
+		{Applied: true, AppliedAt: createdAt, ID: "0003_removed"},
+	}
+	if len(states) != len(expected) {
+		t.Fatalf("Expected %d states, got %+v", len(expected), states)
+	}
+	for i := range expected {
+		if states[i].Applied != expected[i].Applied || !states[i].AppliedAt.Equal(expected[i].AppliedAt) || states[i].ID != expected[i].ID || states[i].Migration != expected[i].Migration {
+			t.Errorf("Expected state %+v, got %+v", expected[i], states[i])
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 )
@@ -19,23 +21,34 @@ type JsonValuer interface {
 }
 
 type Model[T any] struct {
-	Fields        map[string]reflect.StructField
-	PrimaryColumn string
-	PrimaryField  string
-	Table         string
-	Type          reflect.Type
+	Error          error
+	Fields         map[string]reflect.StructField
+	Indexes        []Index
+	PrimaryColumn  string
+	PrimaryColumns []string
+	PrimaryField   string
+	PrimaryFields  []string
+	Table          string
+	Type           reflect.Type
+
+	meta *modelFields
 }
 
-func (model *Model[T]) All(db *sql.DB) ([]*T, error) {
+func (model *Model[T]) All(db Executor) ([]*T, error) {
 	query := &Query[T]{Model: model}
 	return query.All(db)
 }
 
-func (model *Model[T]) AllToMap(db *sql.DB) ([]map[string]interface{}, error) {
+func (model *Model[T]) AllToMap(db Executor) ([]map[string]interface{}, error) {
 	query := &Query[T]{Model: model}
 	return query.AllToMap(db)
 }
 
+func (model *Model[T]) CheckSchema(db Executor) (*TableDiff, error) {
+	query := &Query[T]{Model: model}
+	return query.CheckSchema(db)
+}
+
 func (model *Model[T]) Context(context context.Context) *Query[T] {
 	return &Query[T]{
 		Config: QueryConfig{Context: context},
@@ -43,7 +56,7 @@ func (model *Model[T]) Context(context context.Context) *Query[T] {
 	}
 }
 
-func (model *Model[T]) Count(db *sql.DB) (uint, error) {
+func (model *Model[T]) Count(db Executor) (uint, error) {
 	query := &Query[T]{Model: model}
 	return query.Count(db)
 }
@@ -62,6 +75,11 @@ func (model *Model[T]) FetchRelated(columns ...string) *Query[T] {
 	}
 }
 
+func (model *Model[T]) FetchRelatedQuery(path string, fn interface{}) *Query[T] {
+	query := &Query[T]{Model: model}
+	return query.FetchRelatedQuery(path, fn)
+}
+
 func (model *Model[T]) Filter(column interface{}, operator string, value interface{}) *Query[T] {
 	query := &Query[T]{Model: model}
 	return query.Filter(column, operator, value)
@@ -77,40 +95,101 @@ func (model *Model[T]) FilterOr(clauses ...interface{}) *Query[T] {
 	return query.FilterOr(clauses...)
 }
 
-func (model *Model[T]) Insert(db *sql.DB, row *T) (sql.Result, error) {
+func (model *Model[T]) ForShare() *Query[T] {
+	query := &Query[T]{Model: model}
+	return query.ForShare()
+}
+
+func (model *Model[T]) ForUpdate() *Query[T] {
+	query := &Query[T]{Model: model}
+	return query.ForUpdate()
+}
+
+func (model *Model[T]) GroupBy(columns ...interface{}) *Query[T] {
+	return &Query[T]{
+		Config: QueryConfig{GroupBy: columns},
+		Model:  model,
+	}
+}
+
+func (model *Model[T]) Insert(db Executor, row *T) (sql.Result, error) {
 	query := &Query[T]{Model: model}
 	return query.Insert(db, row)
 }
 
-func (model *Model[T]) InsertMap(db *sql.DB, data map[string]interface{}) (sql.Result, error) {
+func (model *Model[T]) InsertMany(db Executor, rows []*T) (sql.Result, error) {
+	query := &Query[T]{Model: model}
+	return query.InsertMany(db, rows)
+}
+
+func (model *Model[T]) InsertMap(db Executor, data map[string]interface{}) (sql.Result, error) {
 	query := &Query[T]{Model: model}
 	return query.InsertMap(db, data)
 }
 
+func (model *Model[T]) Iter(db Executor) (*Cursor[T], error) {
+	query := &Query[T]{Model: model}
+	return query.Iter(db)
+}
+
+func (model *Model[T]) metadata() *modelFields {
+	if model.meta == nil {
+		model.meta = newModelFields(model.Fields)
+	}
+	return model.meta
+}
+
+func (model *Model[T]) modelType() reflect.Type {
+	return model.Type
+}
+
 func (model *Model[T]) Query() *Query[T] {
 	return &Query[T]{Model: model}
 }
 
+func (model *Model[T]) returningColumns() []string {
+	columns := make([]string, 0)
+	for column, field := range model.Fields {
+		if field.Tag.Get("db_primary") == "true" || field.Tag.Get("db_default") != "" {
+			columns = append(columns, column)
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
 func (model *Model[T]) Scan(rows *sql.Rows) (*T, error) {
-	data, err := model.ScanToMap(rows)
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	scanner, err := newRowScanner(model, columns)
 	if err != nil {
 		return nil, err
 	}
-	return model.ScanMap(data)
+	return scanner.scan(rows)
 }
 
 func (model *Model[T]) ScanMap(data map[string]interface{}) (*T, error) {
 	var row T
-	value := reflect.ValueOf(&row).Elem()
+	if err := model.scanMapInto(reflect.ValueOf(&row).Elem(), data); err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
 
+func (model *Model[T]) scanMapInto(value reflect.Value, data map[string]interface{}) error {
+	meta := model.metadata()
 	for column, v := range data {
-		if field, ok := model.Fields[column]; ok {
-			if field := value.FieldByName(field.Name); field.IsValid() {
+		if fieldMeta, ok := meta.byColumn[column]; ok && fieldMeta.index != nil {
+			if field := value.FieldByIndex(fieldMeta.index); field.IsValid() {
 				columnValue := reflect.ValueOf(v)
 
 				if v == nil {
 					// database/sql null types (NullString, etc) default to `Valid: false`.
 					// rem.ForeignKey and rem.NullForeignKey also follow this convention.
+					// Zeroing matters when scanning into an existing row, such as after RETURNING.
+					field.Set(reflect.Zero(field.Type()))
 
 				} else if columnValue.CanConvert(field.Type()) {
 					field.Set(columnValue)
@@ -120,36 +199,25 @@ func (model *Model[T]) ScanMap(data map[string]interface{}) (*T, error) {
 						scanner.Scan(v)
 						field.Set(reflect.ValueOf(scanner).Elem())
 
-					} else if strings.HasPrefix(field.Type().String(), "rem.ForeignKey[") || strings.HasPrefix(field.Type().String(), "rem.NullForeignKey[") {
-						subModelQ := field.Addr().MethodByName("Model").Call(nil)
-						subPrimaryField := reflect.Indirect(subModelQ[0]).FieldByName("PrimaryField").Interface().(string)
-						subField := field.FieldByName("Row").Elem().FieldByName(subPrimaryField)
-						if subField.IsValid() {
-							// TODO: Handle primary keys that are nullable types
-							subField.Set(columnValue)
-							field.FieldByName("Valid").SetBool(true)
+					} else if fieldMeta.isForeignKey() {
+						if !columnValue.CanConvert(fieldMeta.relatedPrimaryType) {
+							return fmt.Errorf("rem: unhandled type conversion in scan from '%s' to '%s'", columnValue.Type(), fieldMeta.relatedPrimaryType)
 						}
+						// TODO: Handle primary keys that are nullable types
+						fieldMeta.setForeignKey(value, columnValue.Convert(fieldMeta.relatedPrimaryType))
 					} else {
-						return nil, fmt.Errorf("rem: unhandled struct conversion in scan from '%s' to '%s'", columnValue.Type(), field.Type())
+						return fmt.Errorf("rem: unhandled struct conversion in scan from '%s' to '%s'", columnValue.Type(), field.Type())
 					}
 
 				} else {
-					return nil, fmt.Errorf("rem: unhandled type conversion in scan from '%s' to '%s'", columnValue.Type(), field.Type())
+					return fmt.Errorf("rem: unhandled type conversion in scan from '%s' to '%s'", columnValue.Type(), field.Type())
 				}
 			}
 		}
 	}
 
-	// OneToMany relationships.
-	for _, field := range model.Fields {
-		if strings.HasPrefix(field.Type.String(), "rem.OneToMany[") {
-			oneToMany := value.FieldByName(field.Name)
-			oneToMany.FieldByName("RelatedColumn").SetString(field.Tag.Get("db"))
-			oneToMany.FieldByName("RowPk").Set(value.FieldByName(model.PrimaryField))
-		}
-	}
-
-	return &row, nil
+	model.setRelations(value)
+	return nil
 }
 
 func (model *Model[T]) ScanToMap(rows *sql.Rows) (map[string]interface{}, error) {
@@ -158,41 +226,16 @@ func (model *Model[T]) ScanToMap(rows *sql.Rows) (map[string]interface{}, error)
 		return nil, err
 	}
 
+	meta := model.metadata()
+	fields := make([]*modelField, len(columns))
 	pointers := make([]interface{}, len(columns))
 	for i, column := range columns {
-		field, ok := model.Fields[column]
-		if !ok {
+		field, ok := meta.byColumn[column]
+		if !ok || field.isRelation() {
 			return nil, fmt.Errorf("rem: column '%s' not found on model '%T'", column, model)
 		}
-		fieldType := field.Type
-		if strings.HasPrefix(fieldType.String(), "rem.ForeignKey[") || strings.HasPrefix(fieldType.String(), "rem.NullForeignKey[") {
-			fk := reflect.New(fieldType)
-			q := fk.MethodByName("Model").Call(nil)
-			fkPrimaryField := reflect.Indirect(q[0]).FieldByName("PrimaryField").Interface().(string)
-			pointers[i] = reflect.New(reflect.Indirect(reflect.Indirect(fk).FieldByName("Row")).FieldByName(fkPrimaryField).Type()).Interface()
-			if strings.HasPrefix(fieldType.String(), "rem.NullForeignKey[") {
-				switch pointers[i].(type) {
-				case *bool:
-					pointers[i] = new(sql.NullBool)
-				case *byte:
-					pointers[i] = new(sql.NullByte)
-				case *int, *int64:
-					pointers[i] = new(sql.NullInt64)
-				case *int32:
-					pointers[i] = new(sql.NullInt32)
-				case *int8, *int16:
-					pointers[i] = new(sql.NullInt16)
-				case *float32, *float64:
-					pointers[i] = new(sql.NullFloat64)
-				case *string:
-					pointers[i] = new(sql.NullString)
-				case *time.Time:
-					pointers[i] = new(sql.NullTime)
-				}
-			}
-		} else {
-			pointers[i] = reflect.New(fieldType).Interface()
-		}
+		fields[i] = field
+		pointers[i] = field.scanPointer()
 	}
 
 	if err := rows.Scan(pointers...); err != nil {
@@ -201,6 +244,14 @@ func (model *Model[T]) ScanToMap(rows *sql.Rows) (map[string]interface{}, error)
 
 	row := make(map[string]interface{})
 	for i, column := range columns {
+		if fields[i].kind == fieldNullForeignKey {
+			if pk := reflect.ValueOf(pointers[i]).Elem(); pk.IsNil() {
+				row[column] = nil
+			} else {
+				row[column] = pk.Elem().Interface()
+			}
+			continue
+		}
 		switch vt := reflect.ValueOf(pointers[i]).Elem().Interface().(type) {
 		case driver.Valuer:
 			row[column], _ = vt.Value()
@@ -212,6 +263,11 @@ func (model *Model[T]) ScanToMap(rows *sql.Rows) (map[string]interface{}, error)
 	return row, nil
 }
 
+func (model *Model[T]) SchemaDiff(schema *TableSchema) (*TableDiff, error) {
+	query := &Query[T]{Model: model}
+	return query.SchemaDiff(schema)
+}
+
 func (model *Model[T]) Select(columns ...interface{}) *Query[T] {
 	return &Query[T]{
 		Config: QueryConfig{Selected: columns},
@@ -219,6 +275,18 @@ func (model *Model[T]) Select(columns ...interface{}) *Query[T] {
 	}
 }
 
+func (model *Model[T]) setRelations(value reflect.Value) {
+	for _, field := range model.metadata().relations {
+		relation := value.FieldByIndex(field.index)
+		relation.FieldByName("RelatedColumn").SetString(field.structField.Tag.Get("db"))
+		relation.FieldByName("RowPk").Set(value.FieldByName(model.PrimaryField))
+		if field.kind == fieldManyToMany {
+			relation.FieldByName("ThroughTable").SetString(field.structField.Tag.Get("db_through"))
+			relation.FieldByName("ToColumn").SetString(field.structField.Tag.Get("db_to"))
+		}
+	}
+}
+
 func (model *Model[T]) Sort(columns ...string) *Query[T] {
 	return &Query[T]{
 		Config: QueryConfig{Sort: columns},
@@ -226,8 +294,8 @@ func (model *Model[T]) Sort(columns ...string) *Query[T] {
 	}
 }
 
-func (model *Model[T]) SqlAll(db *sql.DB, sql string, args ...interface{}) ([]*T, error) {
-	rows, err := db.Query(sql, args...)
+func (model *Model[T]) SqlAll(db Executor, sql string, args ...interface{}) ([]*T, error) {
+	rows, err := db.QueryContext(context.Background(), sql, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -238,8 +306,8 @@ func (model *Model[T]) SqlAll(db *sql.DB, sql string, args ...interface{}) ([]*T
 	return query.slice(db)
 }
 
-func (model *Model[T]) SqlAllToMap(db *sql.DB, sql string, args ...interface{}) ([]map[string]interface{}, error) {
-	rows, err := db.Query(sql, args...)
+func (model *Model[T]) SqlAllToMap(db Executor, sql string, args ...interface{}) ([]map[string]interface{}, error) {
+	rows, err := db.QueryContext(context.Background(), sql, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -261,22 +329,32 @@ func (model *Model[T]) SqlAllToMap(db *sql.DB, sql string, args ...interface{})
 	return mapped, nil
 }
 
-func (model *Model[T]) TableColumnAdd(db *sql.DB, column string) (sql.Result, error) {
+func (model *Model[T]) TableColumnAdd(db Executor, column string) (sql.Result, error) {
 	query := &Query[T]{Model: model}
 	return query.TableColumnAdd(db, column)
 }
 
-func (model *Model[T]) TableColumnDrop(db *sql.DB, column string) (sql.Result, error) {
+func (model *Model[T]) TableColumnAlter(db Executor, column string) (sql.Result, error) {
+	query := &Query[T]{Model: model}
+	return query.TableColumnAlter(db, column)
+}
+
+func (model *Model[T]) TableColumnDrop(db Executor, column string) (sql.Result, error) {
 	query := &Query[T]{Model: model}
 	return query.TableColumnDrop(db, column)
 }
 
-func (model *Model[T]) TableCreate(db *sql.DB, tableCreateConfig ...TableCreateConfig) (sql.Result, error) {
+func (model *Model[T]) TableColumnRename(db Executor, from string, to string) (sql.Result, error) {
+	query := &Query[T]{Model: model}
+	return query.TableColumnRename(db, from, to)
+}
+
+func (model *Model[T]) TableCreate(db Executor, tableCreateConfig ...TableCreateConfig) (sql.Result, error) {
 	query := &Query[T]{Model: model}
 	return query.TableCreate(db, tableCreateConfig...)
 }
 
-func (model *Model[T]) TableDrop(db *sql.DB, tableDropConfig ...TableDropConfig) (sql.Result, error) {
+func (model *Model[T]) TableDrop(db Executor, tableDropConfig ...TableDropConfig) (sql.Result, error) {
 	query := &Query[T]{Model: model}
 	if len(tableDropConfig) > 0 {
 		return query.TableDrop(db, tableDropConfig[0])
@@ -284,6 +362,41 @@ func (model *Model[T]) TableDrop(db *sql.DB, tableDropConfig ...TableDropConfig)
 	return query.TableDrop(db, TableDropConfig{})
 }
 
+func (model *Model[T]) TableIndexCreate(db Executor, name string, tableIndexCreateConfig ...TableIndexCreateConfig) (sql.Result, error) {
+	query := &Query[T]{Model: model}
+	return query.TableIndexCreate(db, name, tableIndexCreateConfig...)
+}
+
+func (model *Model[T]) TableIndexDrop(db Executor, name string, tableIndexDropConfig ...TableIndexDropConfig) (sql.Result, error) {
+	query := &Query[T]{Model: model}
+	return query.TableIndexDrop(db, name, tableIndexDropConfig...)
+}
+
+func (model *Model[T]) TableIntrospect(db Executor) (*TableSchema, error) {
+	query := &Query[T]{Model: model}
+	return query.TableIntrospect(db)
+}
+
+func (model *Model[T]) TableRename(db Executor, name string) (sql.Result, error) {
+	query := &Query[T]{Model: model}
+	return query.TableRename(db, name)
+}
+
+func (model *Model[T]) TableSchema() (*TableSchema, error) {
+	query := &Query[T]{Model: model}
+	return query.TableSchema()
+}
+
+func (model *Model[T]) TableThroughCreate(db Executor, field string, tableCreateConfig ...TableCreateConfig) (sql.Result, error) {
+	query := &Query[T]{Model: model}
+	return query.TableThroughCreate(db, field, tableCreateConfig...)
+}
+
+func (model *Model[T]) TableThroughDrop(db Executor, field string, tableDropConfig ...TableDropConfig) (sql.Result, error) {
+	query := &Query[T]{Model: model}
+	return query.TableThroughDrop(db, field, tableDropConfig...)
+}
+
 func (model *Model[T]) ToJsonMap(row *T) map[string]interface{} {
 	result := make(map[string]interface{}, 0)
 	value := reflect.ValueOf(row).Elem()
@@ -308,12 +421,14 @@ func (model *Model[T]) ToMap(row *T) (map[string]interface{}, error) {
 	args := make(map[string]interface{})
 	value := reflect.ValueOf(*row)
 
-	for column := range model.Fields {
-		fieldName := model.Fields[column].Name
-		field := value.FieldByName(fieldName)
+	for column, meta := range model.metadata().byColumn {
+		if meta.isRelation() {
+			continue
+		}
+		field := value.FieldByIndex(meta.index)
 
-		// Skip zero valued primary keys.
-		if field.IsZero() && model.Fields[column].Tag.Get("db_primary") == "true" {
+		// Skip zero valued primary keys. Composite primary keys are never generated by the database.
+		if field.IsZero() && meta.structField.Tag.Get("db_primary") == "true" && len(model.PrimaryColumns) <= 1 {
 			continue
 		}
 
@@ -328,16 +443,12 @@ func (model *Model[T]) ToMap(row *T) (map[string]interface{}, error) {
 				args[column] = vv
 
 			default:
-				if strings.HasPrefix(field.Type().String(), "rem.ForeignKey[") || strings.HasPrefix(field.Type().String(), "rem.NullForeignKey[") {
+				if meta.isForeignKey() {
 					if !field.FieldByName("Valid").Interface().(bool) {
 						args[column] = nil
 					} else {
-						q := reflect.New(field.Type()).MethodByName("Model").Call(nil)
-						fkPrimaryField := reflect.Indirect(q[0]).FieldByName("PrimaryField").Interface().(string)
-						args[column] = reflect.Indirect(field.FieldByName("Row")).FieldByName(fkPrimaryField).Interface()
+						args[column] = field.FieldByName("Row").Elem().FieldByIndex(meta.relatedPrimaryIndex).Interface()
 					}
-				} else if strings.HasPrefix(field.Type().String(), "rem.OneToMany[") {
-					continue
 				} else {
 					return nil, fmt.Errorf("rem: unsupported field type '%s' for column '%s' on table '%s'", field.Type().String(), column, model.Table)
 				}
@@ -358,6 +469,16 @@ func (model *Model[T]) Transaction(transaction *sql.Tx) *Query[T] {
 	}
 }
 
+func (model *Model[T]) Upsert(db Executor, row *T, conflictColumns []string, updateColumns ...string) (sql.Result, error) {
+	query := &Query[T]{Model: model}
+	return query.Upsert(db, row, conflictColumns, updateColumns...)
+}
+
+func (model *Model[T]) UpsertDoNothing(db Executor, row *T, conflictColumns ...string) (sql.Result, error) {
+	query := &Query[T]{Model: model}
+	return query.UpsertDoNothing(db, row, conflictColumns...)
+}
+
 func (model *Model[T]) Zero() T {
 	var zero T
 	return zero
@@ -365,57 +486,62 @@ func (model *Model[T]) Zero() T {
 
 type TableCreateConfig struct {
 	IfNotExists bool
+	Indexes     bool
 }
 
 type TableDropConfig struct {
 	IfExists bool
 }
 
-var registeredModels = make(map[string]interface{})
-
-func Register[T any](configs ...Config) *Model[T] {
-	var model T
-	modelType := reflect.TypeOf(model)
-	modelTypeStr := modelType.String()
-	for _, config := range configs {
-		modelTypeStr = fmt.Sprintf("%s%+v", modelTypeStr, config)
-	}
-
-	m := Use[T](configs...)
-	registeredModels[modelTypeStr] = m
-	return m
+type UpsertConfig struct {
+	ConflictColumns []string
+	DoNothing       bool
+	UpdateColumns   []string
 }
 
-func Use[T any](configs ...Config) *Model[T] {
+func newModel[T any](configs ...Config) *Model[T] {
 	var model T
 	modelType := reflect.TypeOf(model)
-	modelTypeStr := modelType.String()
-	for _, config := range configs {
-		modelTypeStr = fmt.Sprintf("%s%+v", modelTypeStr, config)
-	}
-
-	if existing, ok := registeredModels[modelTypeStr]; ok {
-		return existing.(*Model[T])
-	}
 
-	var primaryColumn string
-	var primaryField string
+	primaryColumns := make([]string, 0)
+	primaryFields := make([]string, 0)
 	fields := make(map[string]reflect.StructField, 0)
+	var modelErr error
 
 	for _, field := range reflect.VisibleFields(modelType) {
 		if column, ok := field.Tag.Lookup("db"); ok {
-			if strings.HasPrefix(field.Type.String(), "rem.OneToMany[") {
+			relation, isRelation := reflect.New(field.Type).Interface().(relationField)
+			if isRelation && (relation.relationKind() == fieldOneToMany || relation.relationKind() == fieldManyToMany) {
 				fields[field.Name] = field
+			} else if isRelation && (strings.Contains(column, ",") || len(primaryStructFields(relation.relatedType())) > 1) {
+				compositeFields, err := compositeForeignKeyFields(field, column, relation.relatedType())
+				if err != nil {
+					modelErr = errors.Join(modelErr, err)
+				}
+				if field.Tag.Get("db_primary") == "true" {
+					// Primary keys are read from one field per column, which a composite foreign key doesn't have.
+					modelErr = errors.Join(modelErr, fmt.Errorf("rem: composite foreign key field '%s' can't be part of the primary key. Declare a field for each column instead", field.Name))
+				}
+				for compositeColumn, compositeField := range compositeFields {
+					fields[compositeColumn] = compositeField
+				}
 			} else {
 				fields[column] = field
 				if field.Tag.Get("db_primary") == "true" {
-					primaryColumn = column
-					primaryField = field.Name
+					primaryColumns = append(primaryColumns, column)
+					primaryFields = append(primaryFields, field.Name)
 				}
 			}
 		}
 	}
 
+	var primaryColumn string
+	var primaryField string
+	if len(primaryColumns) > 0 {
+		primaryColumn = primaryColumns[0]
+		primaryField = primaryFields[0]
+	}
+
 	table := strings.ToLower(modelType.Name())
 	for _, config := range configs {
 		if config.Table != "" {
@@ -423,11 +549,30 @@ func Use[T any](configs ...Config) *Model[T] {
 		}
 	}
 
+	indexes, err := modelIndexes(modelType, table, fields)
+	if err != nil {
+		modelErr = errors.Join(modelErr, err)
+	}
+
 	return &Model[T]{
-		Fields:        fields,
-		PrimaryColumn: primaryColumn,
-		PrimaryField:  primaryField,
-		Table:         table,
-		Type:          modelType,
+		Error:          modelErr,
+		Fields:         fields,
+		Indexes:        indexes,
+		PrimaryColumn:  primaryColumn,
+		PrimaryColumns: primaryColumns,
+		PrimaryField:   primaryField,
+		PrimaryFields:  primaryFields,
+		Table:          table,
+		Type:           modelType,
+
+		meta: newModelFields(fields),
 	}
 }
+
+func Register[T any](configs ...Config) *Model[T] {
+	return RegisterIn[T](defaultRegistry, configs...)
+}
+
+func Use[T any](configs ...Config) *Model[T] {
+	return UseIn[T](defaultRegistry, configs...)
+}
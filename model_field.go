@@ -0,0 +1,271 @@
+package rem
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type fieldKind int
+
+const (
+	fieldColumn fieldKind = iota
+	fieldForeignKey
+	fieldNullForeignKey
+	fieldOneToMany
+	fieldManyToMany
+)
+
+type modelField struct {
+	column               string
+	index                []int
+	kind                 fieldKind
+	name                 string
+	relatedForeignField  string
+	relatedKeyColumns    []string
+	relatedKeyIndexes    [][]int
+	relatedPrimaryColumn string
+	relatedPrimaryField  string
+	relatedPrimaryIndex  []int
+	relatedPrimaryType   reflect.Type
+	relatedType          reflect.Type
+	structField          reflect.StructField
+}
+
+func (field *modelField) isCompositeForeignKey() bool {
+	return field.isForeignKey() && len(field.relatedKeyColumns) > 0
+}
+
+func (field *modelField) isRelation() bool {
+	return field.kind == fieldOneToMany || field.kind == fieldManyToMany
+}
+
+func (field *modelField) isForeignKey() bool {
+	return field.kind == fieldForeignKey || field.kind == fieldNullForeignKey
+}
+
+func (field *modelField) scanPointer() interface{} {
+	switch field.kind {
+	case fieldForeignKey:
+		return reflect.New(field.relatedPrimaryType).Interface()
+	case fieldNullForeignKey:
+		return reflect.New(reflect.PointerTo(field.relatedPrimaryType)).Interface()
+	}
+	return reflect.New(field.structField.Type).Interface()
+}
+
+func (field *modelField) relatedKey(row reflect.Value) interface{} {
+	if !field.isCompositeForeignKey() {
+		return row.FieldByIndex(field.relatedPrimaryIndex).Interface()
+	}
+	key := make([]interface{}, len(field.relatedKeyIndexes))
+	for i, index := range field.relatedKeyIndexes {
+		key[i] = row.FieldByIndex(index).Interface()
+	}
+	return key
+}
+
+func (field *modelField) relatedKeyEqual(a reflect.Value, b reflect.Value) bool {
+	if !field.isCompositeForeignKey() {
+		return a.FieldByIndex(field.relatedPrimaryIndex).Interface() == b.FieldByIndex(field.relatedPrimaryIndex).Interface()
+	}
+	for _, index := range field.relatedKeyIndexes {
+		if a.FieldByIndex(index).Interface() != b.FieldByIndex(index).Interface() {
+			return false
+		}
+	}
+	return true
+}
+
+func (field *modelField) scanForeignKey(value reflect.Value, pointer interface{}) {
+	pk := reflect.ValueOf(pointer).Elem()
+	if field.kind == fieldNullForeignKey {
+		if pk.IsNil() {
+			fk := value.FieldByIndex(field.index)
+			fk.Set(reflect.Zero(fk.Type()))
+			return
+		}
+		pk = pk.Elem()
+	}
+	field.setForeignKey(value, pk)
+}
+
+func (field *modelField) setForeignKey(value reflect.Value, pk reflect.Value) {
+	fk := value.FieldByIndex(field.index)
+	row := fk.Field(0)
+	if row.IsNil() || !field.isCompositeForeignKey() {
+		row.Set(reflect.New(field.relatedType))
+	}
+	row.Elem().FieldByIndex(field.relatedPrimaryIndex).Set(pk)
+	fk.Field(1).SetBool(true)
+}
+
+type modelFields struct {
+	byColumn  map[string]*modelField
+	byName    map[string]*modelField
+	relations []*modelField
+}
+
+type relationField interface {
+	relationKind() fieldKind
+	relatedType() reflect.Type
+}
+
+type rowScanner[T any] struct {
+	fields   []*modelField
+	model    *Model[T]
+	pointers []interface{}
+}
+
+func (scanner *rowScanner[T]) scan(rows *sql.Rows) (*T, error) {
+	var row T
+	value := reflect.ValueOf(&row).Elem()
+	for i, field := range scanner.fields {
+		if field.kind == fieldColumn {
+			scanner.pointers[i] = value.FieldByIndex(field.index).Addr().Interface()
+		}
+	}
+
+	if err := rows.Scan(scanner.pointers...); err != nil {
+		return nil, err
+	}
+
+	for i, field := range scanner.fields {
+		if field.isForeignKey() {
+			field.scanForeignKey(value, scanner.pointers[i])
+		}
+	}
+	scanner.model.setRelations(value)
+	return &row, nil
+}
+
+func columnStructField(modelType reflect.Type, column string) (reflect.StructField, bool) {
+	if modelType.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+	for _, field := range reflect.VisibleFields(modelType) {
+		if field.Tag.Get("db") == column {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func compositeForeignKeyFields(field reflect.StructField, tagColumns string, relatedType reflect.Type) (map[string]reflect.StructField, error) {
+	columns := strings.Split(tagColumns, ",")
+	primaries := primaryStructFields(relatedType)
+	if len(columns) != len(primaries) {
+		return nil, fmt.Errorf("rem: foreign key field '%s' has %d columns, but '%s' has %d primary key columns", field.Name, len(columns), relatedType, len(primaries))
+	}
+
+	fields := make(map[string]reflect.StructField, len(columns))
+	for i, column := range columns {
+		column = strings.TrimSpace(column)
+		compositeField := field
+		compositeField.Tag = reflect.StructTag(strings.Replace(
+			string(field.Tag),
+			fmt.Sprintf(`db:"%s"`, tagColumns),
+			fmt.Sprintf(`db:"%s" db_references:"%s"`, column, primaries[i].Tag.Get("db")),
+			1))
+		fields[column] = compositeField
+	}
+	return fields, nil
+}
+
+func newModelFields(fields map[string]reflect.StructField) *modelFields {
+	meta := &modelFields{
+		byColumn:  make(map[string]*modelField, len(fields)),
+		byName:    make(map[string]*modelField, len(fields)),
+		relations: make([]*modelField, 0),
+	}
+	for column, structField := range fields {
+		field := &modelField{
+			column:      column,
+			index:       structField.Index,
+			kind:        fieldColumn,
+			name:        structField.Name,
+			structField: structField,
+		}
+		if structField.Type != nil {
+			if relation, ok := reflect.New(structField.Type).Interface().(relationField); ok {
+				field.kind = relation.relationKind()
+				field.relatedType = relation.relatedType()
+				primaries := primaryStructFields(field.relatedType)
+				primary, ok := reflect.StructField{}, len(primaries) > 0
+				if references, isComposite := structField.Tag.Lookup("db_references"); isComposite {
+					primary, ok = columnStructField(field.relatedType, references)
+					for _, primary := range primaries {
+						field.relatedKeyColumns = append(field.relatedKeyColumns, primary.Tag.Get("db"))
+						field.relatedKeyIndexes = append(field.relatedKeyIndexes, primary.Index)
+					}
+				} else if ok {
+					primary = primaries[0]
+				}
+				if ok {
+					field.relatedPrimaryColumn = primary.Tag.Get("db")
+					field.relatedPrimaryField = primary.Name
+					field.relatedPrimaryIndex = primary.Index
+					field.relatedPrimaryType = primary.Type
+				}
+				if field.isRelation() {
+					field.column = structField.Tag.Get("db")
+				}
+				if field.kind == fieldOneToMany {
+					if foreign, ok := columnStructField(field.relatedType, field.column); ok {
+						field.relatedForeignField = foreign.Name
+					}
+				}
+			}
+		}
+		if field.isForeignKey() && field.relatedPrimaryType == nil {
+			// Without a primary key on the related model the column is scanned as-is.
+			field.kind = fieldColumn
+		}
+		meta.byColumn[column] = field
+		if field.name != "" {
+			meta.byName[field.name] = field
+		}
+		if field.isRelation() {
+			meta.relations = append(meta.relations, field)
+		}
+	}
+	sort.Slice(meta.relations, func(i, j int) bool {
+		return meta.relations[i].name < meta.relations[j].name
+	})
+	return meta
+}
+
+func newRowScanner[T any](model *Model[T], columns []string) (*rowScanner[T], error) {
+	meta := model.metadata()
+	scanner := &rowScanner[T]{
+		fields:   make([]*modelField, len(columns)),
+		model:    model,
+		pointers: make([]interface{}, len(columns)),
+	}
+	for i, column := range columns {
+		field, ok := meta.byColumn[column]
+		if !ok || field.isRelation() || field.index == nil {
+			return nil, fmt.Errorf("rem: column '%s' not found on model '%T'", column, model)
+		}
+		scanner.fields[i] = field
+		if field.kind != fieldColumn {
+			scanner.pointers[i] = field.scanPointer()
+		}
+	}
+	return scanner, nil
+}
+
+func primaryStructFields(modelType reflect.Type) []reflect.StructField {
+	fields := make([]reflect.StructField, 0)
+	if modelType.Kind() != reflect.Struct {
+		return fields
+	}
+	for _, field := range reflect.VisibleFields(modelType) {
+		if _, ok := field.Tag.Lookup("db"); ok && field.Tag.Get("db_primary") == "true" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
@@ -307,9 +307,7 @@ func TestModelToMap(t *testing.T) {
 }
 
 func TestRegister(t *testing.T) {
-	defer func() {
-		registeredModels = make(map[string]interface{})
-	}()
+	defer defaultRegistry.Reset()
 	type testModel struct {
 		Id   int64  `db:"id" db_primary:"true"`
 		Name string `db:"name"`
@@ -318,8 +316,8 @@ func TestRegister(t *testing.T) {
 	m2 := Register[testModel]()
 	m3 := Use[testModel]()
 	m4 := Use[testModel](Config{Table: "testmodelwithconfig"})
-	if m1 == m2 || m1 == m3 {
-		t.Errorf("Expected '%#v' to be different from '%#v' and '%#v'", m1, m2, m3)
+	if m1 == m2 {
+		t.Errorf("Expected '%#v' to be different from '%#v'", m1, m2)
 	}
 	if m2 != m3 {
 		t.Errorf("Expected '%#v', got '%#v'", m2, m3)
@@ -327,6 +325,70 @@ func TestRegister(t *testing.T) {
 	if m2 == m4 {
 		t.Errorf("Expected '%#v' to be different from '%#v'", m2, m4)
 	}
+	if m5 := Use[testModel](Config{Table: "testmodelwithconfig"}); m4 != m5 {
+		t.Errorf("Expected '%#v', got '%#v'", m4, m5)
+	}
+}
+
+func TestScan(t *testing.T) {
+	type testGroups struct {
+		Id   int64  `db:"id" db_primary:"true"`
+		Name string `db:"name"`
+	}
+	type testAccounts struct {
+		EditedAt sql.NullTime               `db:"edited_at"`
+		Group    ForeignKey[testGroups]     `db:"group_id"`
+		Id       int64                      `db:"id" db_primary:"true"`
+		Name     string                     `db:"name"`
+		Parent   NullForeignKey[testGroups] `db:"parent_id"`
+	}
+	accounts := Use[testAccounts]()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "name", "group_id", "parent_id", "edited_at"}).
+		AddRow(1, "foo", 10, 20, time.Date(2009, time.January, 2, 3, 0, 0, 0, time.UTC)).
+		AddRow(2, "bar", 30, nil, nil)
+
+	mock.ExpectQuery("SELECT").WillReturnRows(rows)
+	rs, _ := db.Query("SELECT")
+	defer rs.Close()
+
+	expected := []testAccounts{
+		{
+			EditedAt: sql.NullTime{Time: time.Date(2009, time.January, 2, 3, 0, 0, 0, time.UTC), Valid: true},
+			Group:    ForeignKey[testGroups]{Row: &testGroups{Id: 10}, Valid: true},
+			Id:       1,
+			Name:     "foo",
+			Parent:   NullForeignKey[testGroups]{Row: &testGroups{Id: 20}, Valid: true},
+		},
+		{
+			Group: ForeignKey[testGroups]{Row: &testGroups{Id: 30}, Valid: true},
+			Id:    2,
+			Name:  "bar",
+		},
+	}
+	i := 0
+	for rs.Next() {
+		row, err := accounts.Scan(rs)
+		if err != nil {
+			t.Fatal("Unexpected error:", err)
+		}
+		if row.Id != expected[i].Id ||
+			row.Name != expected[i].Name ||
+			row.EditedAt != expected[i].EditedAt ||
+			row.Group.Valid != expected[i].Group.Valid ||
+			row.Group.Row.Id != expected[i].Group.Row.Id ||
+			row.Parent.Valid != expected[i].Parent.Valid ||
+			(row.Parent.Valid && row.Parent.Row.Id != expected[i].Parent.Row.Id) {
+			t.Errorf("Expected '%+v', got '%+v'", expected[i], row)
+		}
+		i++
+	}
 }
 
 func TestScanToMap(t *testing.T) {
@@ -1,8 +1,11 @@
 package mysqldialect
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"sort"
 	"strings"
@@ -10,10 +13,13 @@ import (
 
 	"github.com/evantbyrne/rem"
 	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 )
 
 type MysqlDialect struct{}
 
+const migrationLockName = "rem_migrations"
+
 func (dialect MysqlDialect) BuildDelete(config rem.QueryConfig) (string, []interface{}, error) {
 	args := append([]interface{}(nil), config.Params...)
 	var queryString strings.Builder
@@ -62,42 +68,74 @@ func (dialect MysqlDialect) BuildDelete(config rem.QueryConfig) (string, []inter
 }
 
 func (dialect MysqlDialect) BuildInsert(config rem.QueryConfig, rowMap map[string]interface{}, columns ...string) (string, []interface{}, error) {
+	return dialect.BuildInsertMany(config, []map[string]interface{}{rowMap}, columns...)
+}
+
+func (dialect MysqlDialect) BuildInsertMany(config rem.QueryConfig, rowMaps []map[string]interface{}, columns ...string) (string, []interface{}, error) {
 	args := make([]interface{}, 0)
 	var queryString strings.Builder
 
+	if len(rowMaps) == 0 {
+		return "", nil, fmt.Errorf("rem: no rows specified for INSERT")
+	}
+
 	queryString.WriteString("INSERT INTO ")
 	queryString.WriteString(dialect.QuoteIdentifier(config.Table))
 	queryString.WriteString(" (")
-	first := true
-	for _, column := range columns {
-		if arg, ok := rowMap[column]; ok {
-			if _, ok := config.Fields[column]; !ok {
-				return "", nil, fmt.Errorf("rem: field for column '%s' not found on model for table '%s'", column, config.Table)
+	for i, column := range columns {
+		if _, ok := config.Fields[column]; !ok {
+			return "", nil, fmt.Errorf("rem: field for column '%s' not found on model for table '%s'", column, config.Table)
+		}
+		if i > 0 {
+			queryString.WriteString(",")
+		}
+		queryString.WriteString(dialect.QuoteIdentifier(column))
+	}
+
+	queryString.WriteString(") VALUES ")
+	for i, rowMap := range rowMaps {
+		if i > 0 {
+			queryString.WriteString(",")
+		}
+		queryString.WriteString("(")
+		for j, column := range columns {
+			arg, ok := rowMap[column]
+			if !ok {
+				return "", nil, fmt.Errorf("rem: invalid column '%s' on INSERT", column)
 			}
 			args = append(args, arg)
-			if first {
-				first = false
-			} else {
+			if j > 0 {
 				queryString.WriteString(",")
 			}
-			queryString.WriteString(dialect.QuoteIdentifier(column))
-		} else {
-			return "", nil, fmt.Errorf("rem: invalid column '%s' on INSERT", column)
+			queryString.WriteString(dialect.Param(len(args)))
 		}
+		queryString.WriteString(")")
 	}
 
-	queryString.WriteString(") VALUES (")
-	for i := 1; i <= len(rowMap); i++ {
-		if i > 1 {
-			queryString.WriteString(",")
-		}
-		queryString.WriteString(dialect.Param(i))
+	// RETURNING
+	if len(config.Returning) > 0 {
+		return "", nil, fmt.Errorf("rem: INSERT does not support RETURNING")
 	}
-	queryString.WriteString(")")
 
 	return queryString.String(), args, nil
 }
 
+func (dialect MysqlDialect) buildHaving(config rem.QueryConfig, args []interface{}) (string, []interface{}, error) {
+	var queryPart strings.Builder
+	if len(config.Having) > 0 {
+		queryPart.WriteString(" HAVING")
+		for _, where := range config.Having {
+			queryWhere, whereArgs, err := where.StringWithArgs(dialect, args)
+			if err != nil {
+				return "", nil, err
+			}
+			args = whereArgs
+			queryPart.WriteString(queryWhere)
+		}
+	}
+	return queryPart.String(), args, nil
+}
+
 func (dialect MysqlDialect) buildJoins(config rem.QueryConfig, args []interface{}) (string, []interface{}, error) {
 	var queryPart strings.Builder
 	if len(config.Joins) > 0 {
@@ -118,6 +156,34 @@ func (dialect MysqlDialect) buildJoins(config rem.QueryConfig, args []interface{
 	return queryPart.String(), args, nil
 }
 
+func (dialect MysqlDialect) buildLock(config rem.QueryConfig) (string, error) {
+	if config.Count && (config.ForShare || config.ForUpdate || config.NoWait || config.SkipLocked) {
+		return "", fmt.Errorf("rem: cannot lock rows when counting. Lock them with a separate query")
+	}
+	if config.ForShare && config.ForUpdate {
+		return "", fmt.Errorf("rem: cannot lock rows for both SHARE and UPDATE")
+	}
+	if config.NoWait && config.SkipLocked {
+		return "", fmt.Errorf("rem: cannot use both NOWAIT and SKIP LOCKED")
+	}
+
+	var queryPart strings.Builder
+	if config.ForShare {
+		queryPart.WriteString(" FOR SHARE")
+	} else if config.ForUpdate {
+		queryPart.WriteString(" FOR UPDATE")
+	} else if config.NoWait || config.SkipLocked {
+		return "", fmt.Errorf("rem: NOWAIT and SKIP LOCKED require FOR SHARE or FOR UPDATE")
+	}
+
+	if config.NoWait {
+		queryPart.WriteString(" NOWAIT")
+	} else if config.SkipLocked {
+		queryPart.WriteString(" SKIP LOCKED")
+	}
+	return queryPart.String(), nil
+}
+
 func (dialect MysqlDialect) BuildSelect(config rem.QueryConfig) (string, []interface{}, error) {
 	args := append([]interface{}(nil), config.Params...)
 	var queryString strings.Builder
@@ -167,6 +233,38 @@ func (dialect MysqlDialect) BuildSelect(config rem.QueryConfig) (string, []inter
 		queryString.WriteString(where)
 	}
 
+	// GROUP BY
+	if len(config.GroupBy) > 0 {
+		queryString.WriteString(" GROUP BY ")
+		for i, column := range config.GroupBy {
+			if i > 0 {
+				queryString.WriteString(",")
+			}
+			switch cv := column.(type) {
+			case string:
+				queryString.WriteString(dialect.QuoteIdentifier(cv))
+
+			case rem.DialectStringer:
+				queryString.WriteString(cv.StringForDialect(dialect))
+
+			case fmt.Stringer:
+				queryString.WriteString(cv.String())
+
+			default:
+				return "", nil, fmt.Errorf("rem: invalid column type %#v", column)
+			}
+		}
+	}
+
+	// HAVING
+	having, args, err := dialect.buildHaving(config, args)
+	if err != nil {
+		return "", nil, err
+	}
+	if having != "" {
+		queryString.WriteString(having)
+	}
+
 	// ORDER BY
 	if len(config.Sort) > 0 {
 		queryString.WriteString(" ORDER BY ")
@@ -198,6 +296,13 @@ func (dialect MysqlDialect) BuildSelect(config rem.QueryConfig) (string, []inter
 		queryString.WriteString(dialect.Param(len(args)))
 	}
 
+	// FOR UPDATE, FOR SHARE
+	lock, err := dialect.buildLock(config)
+	if err != nil {
+		return "", nil, err
+	}
+	queryString.WriteString(lock)
+
 	return queryString.String(), args, nil
 }
 
@@ -207,17 +312,50 @@ func (dialect MysqlDialect) BuildTableColumnAdd(config rem.QueryConfig, column s
 		return "", fmt.Errorf("rem: invalid column '%s' on model for table '%s'", column, config.Table)
 	}
 
-	columnType, err := dialect.ColumnType(field)
+	columnType, err := dialect.columnType(field, len(config.PrimaryColumns) > 1)
 	if err != nil {
 		return "", err
 	}
 	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", dialect.QuoteIdentifier(config.Table), dialect.QuoteIdentifier(column), columnType), nil
 }
 
+func (dialect MysqlDialect) BuildTableColumnAlter(config rem.QueryConfig, column string) ([]string, error) {
+	field, ok := config.Fields[column]
+	if !ok {
+		return nil, fmt.Errorf("rem: invalid column '%s' on model for table '%s'", column, config.Table)
+	}
+	definition, err := dialect.columnType(field, len(config.PrimaryColumns) > 1)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys and constraints already exist, so only the column definition is modified.
+	columnType, nullable := dialect.parseColumnType(definition)
+	var sql strings.Builder
+	sql.WriteString(fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s", dialect.QuoteIdentifier(config.Table), dialect.QuoteIdentifier(column), columnType))
+	if nullable {
+		sql.WriteString(" NULL")
+	} else {
+		sql.WriteString(" NOT NULL")
+	}
+	if strings.Contains(strings.ToUpper(definition), " AUTO_INCREMENT") {
+		sql.WriteString(" AUTO_INCREMENT")
+	}
+	if tagDefault := field.Tag.Get("db_default"); tagDefault != "" {
+		sql.WriteString(" DEFAULT ")
+		sql.WriteString(tagDefault)
+	}
+	return []string{sql.String()}, nil
+}
+
 func (dialect MysqlDialect) BuildTableColumnDrop(config rem.QueryConfig, column string) (string, error) {
 	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", dialect.QuoteIdentifier(config.Table), dialect.QuoteIdentifier(column)), nil
 }
 
+func (dialect MysqlDialect) BuildTableColumnRename(config rem.QueryConfig, from string, to string) (string, error) {
+	return fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", dialect.QuoteIdentifier(config.Table), dialect.QuoteIdentifier(from), dialect.QuoteIdentifier(to)), nil
+}
+
 func (dialect MysqlDialect) BuildTableCreate(config rem.QueryConfig, tableCreateConfig rem.TableCreateConfig) (string, error) {
 	var sql strings.Builder
 	sql.WriteString("CREATE TABLE ")
@@ -230,7 +368,8 @@ func (dialect MysqlDialect) BuildTableCreate(config rem.QueryConfig, tableCreate
 	sort.Strings(fieldNames)
 	for i, fieldName := range fieldNames {
 		field := config.Fields[fieldName]
-		columnType, err := dialect.ColumnType(field)
+		// Composite primary keys are declared as a table constraint.
+		columnType, err := dialect.columnType(field, len(config.PrimaryColumns) > 1)
 		if err != nil {
 			return "", err
 		}
@@ -242,10 +381,73 @@ func (dialect MysqlDialect) BuildTableCreate(config rem.QueryConfig, tableCreate
 		sql.WriteString(" ")
 		sql.WriteString(columnType)
 	}
+	constraints, err := dialect.buildTableConstraints(config)
+	if err != nil {
+		return "", err
+	}
+	sql.WriteString(constraints)
 	sql.WriteString("\n)")
 	return sql.String(), nil
 }
 
+func (dialect MysqlDialect) buildTableConstraints(config rem.QueryConfig) (string, error) {
+	var sql strings.Builder
+	if len(config.PrimaryColumns) > 1 {
+		sql.WriteString(",\n\tPRIMARY KEY (")
+		for i, column := range config.PrimaryColumns {
+			if i > 0 {
+				sql.WriteString(", ")
+			}
+			sql.WriteString(dialect.QuoteIdentifier(column))
+		}
+		sql.WriteString(")")
+	}
+
+	// Composite foreign keys are grouped by struct field.
+	foreignKeys := make(map[string]map[string]string)
+	foreignKeyFields := make(map[string]reflect.StructField)
+	for column, field := range config.Fields {
+		if references, ok := field.Tag.Lookup("db_references"); ok {
+			if _, ok := foreignKeys[field.Name]; !ok {
+				foreignKeys[field.Name] = make(map[string]string)
+				foreignKeyFields[field.Name] = field
+			}
+			foreignKeys[field.Name][references] = column
+		}
+	}
+	fieldNames := maps.Keys(foreignKeys)
+	sort.Strings(fieldNames)
+	for _, fieldName := range fieldNames {
+		field := foreignKeyFields[fieldName]
+		subModelQ := reflect.New(field.Type).MethodByName("Model").Call(nil)
+		subPrimaryColumns := reflect.Indirect(subModelQ[0]).FieldByName("PrimaryColumns").Interface().([]string)
+		subTable := reflect.Indirect(subModelQ[0]).FieldByName("Table").Interface().(string)
+
+		columns := make([]string, len(subPrimaryColumns))
+		references := make([]string, len(subPrimaryColumns))
+		for i, subPrimaryColumn := range subPrimaryColumns {
+			column, ok := foreignKeys[fieldName][subPrimaryColumn]
+			if !ok {
+				return "", fmt.Errorf("rem: foreign key field '%s' is missing a column for '%s' on table '%s'", fieldName, subPrimaryColumn, subTable)
+			}
+			columns[i] = dialect.QuoteIdentifier(column)
+			references[i] = dialect.QuoteIdentifier(subPrimaryColumn)
+		}
+		sql.WriteString(fmt.Sprintf(",\n\tFOREIGN KEY (%s) REFERENCES %s (%s)", strings.Join(columns, ", "), dialect.QuoteIdentifier(subTable), strings.Join(references, ", ")))
+
+		if tagOnUpdate := field.Tag.Get("db_on_update"); tagOnUpdate != "" {
+			// ON UPDATE.
+			sql.WriteString(fmt.Sprint(" ON UPDATE ", tagOnUpdate))
+		}
+
+		if tagOnDelete := field.Tag.Get("db_on_delete"); tagOnDelete != "" {
+			// ON DELETE.
+			sql.WriteString(fmt.Sprint(" ON DELETE ", tagOnDelete))
+		}
+	}
+	return sql.String(), nil
+}
+
 func (dialect MysqlDialect) BuildTableDrop(config rem.QueryConfig, tableDropConfig rem.TableDropConfig) (string, error) {
 	var queryString strings.Builder
 	queryString.WriteString("DROP TABLE ")
@@ -256,6 +458,62 @@ func (dialect MysqlDialect) BuildTableDrop(config rem.QueryConfig, tableDropConf
 	return queryString.String(), nil
 }
 
+func (dialect MysqlDialect) BuildTableIndexCreate(config rem.QueryConfig, name string, tableIndexCreateConfig rem.TableIndexCreateConfig) (string, error) {
+	var index *rem.Index
+	for i := range config.Indexes {
+		if config.Indexes[i].Name == name {
+			index = &config.Indexes[i]
+			break
+		}
+	}
+	if index == nil {
+		return "", fmt.Errorf("rem: invalid index '%s' on model for table '%s'", name, config.Table)
+	}
+	if len(index.Columns) == 0 {
+		return "", fmt.Errorf("rem: index '%s' on table '%s' has no columns", name, config.Table)
+	}
+	if tableIndexCreateConfig.IfNotExists {
+		return "", fmt.Errorf("rem: CREATE INDEX does not support IF NOT EXISTS")
+	}
+	if index.Where != "" {
+		return "", fmt.Errorf("rem: CREATE INDEX does not support WHERE")
+	}
+
+	var sql strings.Builder
+	sql.WriteString("CREATE ")
+	if index.Unique {
+		sql.WriteString("UNIQUE ")
+	}
+	sql.WriteString("INDEX ")
+	sql.WriteString(dialect.QuoteIdentifier(index.Name))
+	if index.Method != "" {
+		sql.WriteString(" USING ")
+		sql.WriteString(index.Method)
+	}
+	sql.WriteString(" ON ")
+	sql.WriteString(dialect.QuoteIdentifier(config.Table))
+	sql.WriteString(" (")
+	for i, column := range index.Columns {
+		if i > 0 {
+			sql.WriteString(", ")
+		}
+		sql.WriteString(dialect.QuoteIdentifier(column))
+	}
+	sql.WriteString(")")
+	return sql.String(), nil
+}
+
+func (dialect MysqlDialect) BuildTableIndexDrop(config rem.QueryConfig, name string, tableIndexDropConfig rem.TableIndexDropConfig) (string, error) {
+	if tableIndexDropConfig.IfExists {
+		return "", fmt.Errorf("rem: DROP INDEX does not support IF EXISTS")
+	}
+	return fmt.Sprintf("DROP INDEX %s ON %s", dialect.QuoteIdentifier(name), dialect.QuoteIdentifier(config.Table)), nil
+}
+
+func (dialect MysqlDialect) BuildTableRename(config rem.QueryConfig, name string) (string, error) {
+	return fmt.Sprintf("ALTER TABLE %s RENAME TO %s", dialect.QuoteIdentifier(config.Table), dialect.QuoteIdentifier(name)), nil
+}
+
 func (dialect MysqlDialect) BuildUpdate(config rem.QueryConfig, rowMap map[string]interface{}, columns ...string) (string, []interface{}, error) {
 	args := append([]interface{}(nil), config.Params...)
 	var queryString strings.Builder
@@ -290,6 +548,11 @@ func (dialect MysqlDialect) BuildUpdate(config rem.QueryConfig, rowMap map[strin
 		queryString.WriteString(where)
 	}
 
+	// RETURNING
+	if len(config.Returning) > 0 {
+		return "", nil, fmt.Errorf("rem: UPDATE does not support RETURNING")
+	}
+
 	// ORDER BY
 	if len(config.Sort) > 0 {
 		queryString.WriteString(" ORDER BY ")
@@ -322,6 +585,48 @@ func (dialect MysqlDialect) BuildUpdate(config rem.QueryConfig, rowMap map[strin
 	return queryString.String(), args, nil
 }
 
+func (dialect MysqlDialect) BuildUpsert(config rem.QueryConfig, rowMap map[string]interface{}, upsertConfig rem.UpsertConfig, columns ...string) (string, []interface{}, error) {
+	if err := dialect.checkConflictColumns(config, upsertConfig.ConflictColumns, columns); err != nil {
+		return "", nil, err
+	}
+	queryString, args, err := dialect.BuildInsert(config, rowMap, columns...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var sql strings.Builder
+	sql.WriteString(queryString)
+	if upsertConfig.DoNothing {
+		// MySQL has no DO NOTHING, so assign a column to itself.
+		if len(columns) == 0 {
+			return "", nil, fmt.Errorf("rem: no columns specified for INSERT")
+		}
+		sql.WriteString(" ON DUPLICATE KEY UPDATE ")
+		sql.WriteString(dialect.QuoteIdentifier(columns[0]))
+		sql.WriteString(" = ")
+		sql.WriteString(dialect.QuoteIdentifier(columns[0]))
+		return sql.String(), args, nil
+	}
+
+	if len(upsertConfig.UpdateColumns) == 0 {
+		return "", nil, fmt.Errorf("rem: no columns specified for ON DUPLICATE KEY UPDATE")
+	}
+	// The row alias replaces VALUES(), which is deprecated as of MySQL 8.0.20.
+	sql.WriteString(" AS new ON DUPLICATE KEY UPDATE ")
+	for i, column := range upsertConfig.UpdateColumns {
+		if _, ok := config.Fields[column]; !ok {
+			return "", nil, fmt.Errorf("rem: invalid column '%s' on ON DUPLICATE KEY UPDATE", column)
+		}
+		if i > 0 {
+			sql.WriteString(",")
+		}
+		sql.WriteString(dialect.QuoteIdentifier(column))
+		sql.WriteString(" = new.")
+		sql.WriteString(dialect.QuoteIdentifier(column))
+	}
+	return sql.String(), args, nil
+}
+
 func (dialect MysqlDialect) buildWhere(config rem.QueryConfig, args []interface{}) (string, []interface{}, error) {
 	var queryPart strings.Builder
 	if len(config.Filters) > 0 {
@@ -338,7 +643,64 @@ func (dialect MysqlDialect) buildWhere(config rem.QueryConfig, args []interface{
 	return queryPart.String(), args, nil
 }
 
+func (dialect MysqlDialect) checkConflictColumns(config rem.QueryConfig, conflictColumns []string, columns []string) error {
+	if len(conflictColumns) == 0 {
+		return nil
+	}
+
+	// ON DUPLICATE KEY UPDATE applies to every unique key, so conflict columns are honored only when they are the one key the insert can conflict on.
+	keys := make([][]string, 0)
+	if len(config.PrimaryColumns) > 0 {
+		generated := false
+		if len(config.PrimaryColumns) == 1 && !slices.Contains(columns, config.PrimaryColumns[0]) {
+			columnType, err := dialect.columnType(config.Fields[config.PrimaryColumns[0]], false)
+			generated = err == nil && strings.Contains(columnType, "AUTO_INCREMENT")
+		}
+		if !generated {
+			keys = append(keys, config.PrimaryColumns)
+		}
+	}
+	for column, field := range config.Fields {
+		if field.Tag.Get("db_unique") == "true" {
+			keys = append(keys, []string{column})
+		}
+	}
+	for _, index := range config.Indexes {
+		if index.Unique {
+			keys = append(keys, index.Columns)
+		}
+	}
+
+	matched := false
+	for _, key := range keys {
+		if len(key) != len(conflictColumns) {
+			continue
+		}
+		matched = true
+		for _, column := range conflictColumns {
+			if !slices.Contains(key, column) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			break
+		}
+	}
+	if !matched {
+		return fmt.Errorf("rem: conflict columns '%s' are not a unique key on table '%s'", strings.Join(conflictColumns, ","), config.Table)
+	}
+	if len(keys) > 1 {
+		return fmt.Errorf("rem: MySQL applies ON DUPLICATE KEY UPDATE to every unique key, so conflict columns '%s' can't be honored on table '%s' with %d unique keys", strings.Join(conflictColumns, ","), config.Table, len(keys))
+	}
+	return nil
+}
+
 func (dialect MysqlDialect) ColumnType(field reflect.StructField) (string, error) {
+	return dialect.columnType(field, false)
+}
+
+func (dialect MysqlDialect) columnType(field reflect.StructField, compositePrimary bool) (string, error) {
 	tagType := field.Tag.Get("db_type")
 	if tagType != "" {
 		return tagType, nil
@@ -349,7 +711,7 @@ func (dialect MysqlDialect) ColumnType(field reflect.StructField) (string, error
 	var columnPrimary string
 	var columnType string
 
-	if field.Tag.Get("db_primary") == "true" {
+	if field.Tag.Get("db_primary") == "true" && !compositePrimary {
 		columnPrimary = " PRIMARY KEY"
 
 		switch fieldInstance.(type) {
@@ -452,8 +814,11 @@ func (dialect MysqlDialect) ColumnType(field reflect.StructField) (string, error
 				subModelQ := fv.Addr().MethodByName("Model").Call(nil)
 				subFields := reflect.Indirect(subModelQ[0]).FieldByName("Fields").Interface().(map[string]reflect.StructField)
 				subPrimaryColumn := reflect.Indirect(subModelQ[0]).FieldByName("PrimaryColumn").Interface().(string)
+				if references, ok := field.Tag.Lookup("db_references"); ok {
+					subPrimaryColumn = references
+				}
 				subTable := reflect.Indirect(subModelQ[0]).FieldByName("Table").Interface().(string)
-				columnTypeTemp, err := dialect.ColumnType(subFields[subPrimaryColumn])
+				columnTypeTemp, err := dialect.columnType(subFields[subPrimaryColumn], false)
 				if err != nil {
 					return "", err
 				}
@@ -464,16 +829,19 @@ func (dialect MysqlDialect) ColumnType(field reflect.StructField) (string, error
 				if strings.HasPrefix(field.Type.String(), "rem.NullForeignKey[") {
 					columnNull = " NULL"
 				}
-				columnNull = fmt.Sprintf("%s REFERENCES %s (%s)", columnNull, dialect.QuoteIdentifier(subTable), dialect.QuoteIdentifier(subPrimaryColumn))
+				// Composite foreign keys are declared as a table constraint.
+				if _, ok := field.Tag.Lookup("db_references"); !ok {
+					columnNull = fmt.Sprintf("%s REFERENCES %s (%s)", columnNull, dialect.QuoteIdentifier(subTable), dialect.QuoteIdentifier(subPrimaryColumn))
 
-				if tagOnUpdate := field.Tag.Get("db_on_update"); tagOnUpdate != "" {
-					// ON UPDATE.
-					columnNull = fmt.Sprint(columnNull, " ON UPDATE ", tagOnUpdate)
-				}
+					if tagOnUpdate := field.Tag.Get("db_on_update"); tagOnUpdate != "" {
+						// ON UPDATE.
+						columnNull = fmt.Sprint(columnNull, " ON UPDATE ", tagOnUpdate)
+					}
 
-				if tagOnDelete := field.Tag.Get("db_on_delete"); tagOnDelete != "" {
-					// ON DELETE.
-					columnNull = fmt.Sprint(columnNull, " ON DELETE ", tagOnDelete)
+					if tagOnDelete := field.Tag.Get("db_on_delete"); tagOnDelete != "" {
+						// ON DELETE.
+						columnNull = fmt.Sprint(columnNull, " ON DELETE ", tagOnDelete)
+					}
 				}
 			}
 		}
@@ -496,10 +864,228 @@ func (dialect MysqlDialect) ColumnType(field reflect.StructField) (string, error
 	return fmt.Sprint(columnType, columnPrimary, columnNull), nil
 }
 
+func (dialect MysqlDialect) CompositePrimaryColumnType(field reflect.StructField) (string, error) {
+	return dialect.columnType(field, true)
+}
+
+func (dialect MysqlDialect) introspectColumns(ctx context.Context, db rem.Executor, table string) ([]rem.ColumnSchema, error) {
+	rows, err := db.QueryContext(ctx, `SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT, EXTRA
+FROM information_schema.COLUMNS
+WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?
+ORDER BY ORDINAL_POSITION`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make([]rem.ColumnSchema, 0)
+	for rows.Next() {
+		var column rem.ColumnSchema
+		var extra string
+		var isNullable string
+		if err := rows.Scan(&column.Name, &column.Type, &isNullable, &column.Default, &extra); err != nil {
+			return nil, err
+		}
+		column.Type = strings.ToUpper(column.Type)
+		column.Nullable = isNullable == "YES"
+		column.AutoIncrement = strings.Contains(strings.ToLower(extra), "auto_increment")
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
+
+func (dialect MysqlDialect) introspectForeignKeys(ctx context.Context, db rem.Executor, table string) ([]rem.ForeignKeySchema, error) {
+	rows, err := db.QueryContext(ctx, `SELECT kcu.CONSTRAINT_NAME, kcu.COLUMN_NAME, kcu.REFERENCED_TABLE_NAME, kcu.REFERENCED_COLUMN_NAME, rc.UPDATE_RULE, rc.DELETE_RULE
+FROM information_schema.KEY_COLUMN_USAGE kcu
+JOIN information_schema.REFERENTIAL_CONSTRAINTS rc ON rc.CONSTRAINT_SCHEMA = kcu.CONSTRAINT_SCHEMA AND rc.CONSTRAINT_NAME = kcu.CONSTRAINT_NAME
+WHERE kcu.TABLE_SCHEMA = DATABASE() AND kcu.TABLE_NAME = ? AND kcu.REFERENCED_TABLE_NAME IS NOT NULL
+ORDER BY kcu.CONSTRAINT_NAME, kcu.ORDINAL_POSITION`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	foreignKeys := make([]rem.ForeignKeySchema, 0)
+	for rows.Next() {
+		var column, name, onDelete, onUpdate, referencedColumn, referencedTable string
+		if err := rows.Scan(&name, &column, &referencedTable, &referencedColumn, &onUpdate, &onDelete); err != nil {
+			return nil, err
+		}
+		if len(foreignKeys) == 0 || foreignKeys[len(foreignKeys)-1].Name != name {
+			foreignKeys = append(foreignKeys, rem.ForeignKeySchema{
+				Name:            name,
+				OnDelete:        onDelete,
+				OnUpdate:        onUpdate,
+				ReferencedTable: referencedTable,
+			})
+		}
+		foreignKey := &foreignKeys[len(foreignKeys)-1]
+		foreignKey.Columns = append(foreignKey.Columns, column)
+		foreignKey.ReferencedColumns = append(foreignKey.ReferencedColumns, referencedColumn)
+	}
+	return foreignKeys, rows.Err()
+}
+
+func (dialect MysqlDialect) introspectIndexes(ctx context.Context, db rem.Executor, table string) ([]rem.Index, error) {
+	rows, err := db.QueryContext(ctx, `SELECT INDEX_NAME, NON_UNIQUE, INDEX_TYPE, COLUMN_NAME
+FROM information_schema.STATISTICS
+WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND INDEX_NAME <> 'PRIMARY'
+ORDER BY INDEX_NAME, SEQ_IN_INDEX`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	indexes := make([]rem.Index, 0)
+	for rows.Next() {
+		var column, method, name string
+		var nonUnique int
+		if err := rows.Scan(&name, &nonUnique, &method, &column); err != nil {
+			return nil, err
+		}
+		if len(indexes) == 0 || indexes[len(indexes)-1].Name != name {
+			index := rem.Index{
+				Name:   name,
+				Unique: nonUnique == 0,
+			}
+			if method != "BTREE" {
+				index.Method = method
+			}
+			indexes = append(indexes, index)
+		}
+		index := &indexes[len(indexes)-1]
+		index.Columns = append(index.Columns, column)
+	}
+	return indexes, rows.Err()
+}
+
+func (dialect MysqlDialect) introspectPrimaryKey(ctx context.Context, db rem.Executor, table string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT COLUMN_NAME
+FROM information_schema.KEY_COLUMN_USAGE
+WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND CONSTRAINT_NAME = 'PRIMARY'
+ORDER BY ORDINAL_POSITION`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	primaryKey := make([]string, 0)
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, err
+		}
+		primaryKey = append(primaryKey, column)
+	}
+	return primaryKey, rows.Err()
+}
+
+func errorField(err error, name string) (reflect.Value, bool) {
+	if err == nil {
+		return reflect.Value{}, false
+	}
+	value := reflect.Indirect(reflect.ValueOf(err))
+	if value.Kind() == reflect.Struct {
+		if field := value.FieldByName(name); field.IsValid() {
+			return field, true
+		}
+	}
+	switch wrapped := err.(type) {
+	case interface{ Unwrap() error }:
+		return errorField(wrapped.Unwrap(), name)
+	case interface{ Unwrap() []error }:
+		for _, err := range wrapped.Unwrap() {
+			if field, ok := errorField(err, name); ok {
+				return field, true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func (dialect MysqlDialect) IntrospectTable(ctx context.Context, db rem.Executor, table string) (*rem.TableSchema, error) {
+	columns, err := dialect.introspectColumns(ctx, db, table)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%w: %s", rem.ErrTableNotExist, table)
+	}
+	primaryKey, err := dialect.introspectPrimaryKey(ctx, db, table)
+	if err != nil {
+		return nil, err
+	}
+	foreignKeys, err := dialect.introspectForeignKeys(ctx, db, table)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := dialect.introspectIndexes(ctx, db, table)
+	if err != nil {
+		return nil, err
+	}
+	return &rem.TableSchema{
+		Columns:     columns,
+		ForeignKeys: foreignKeys,
+		Indexes:     indexes,
+		Name:        table,
+		PrimaryKey:  primaryKey,
+	}, nil
+}
+
+func (dialect MysqlDialect) IsRetryableError(err error) bool {
+	// go-sql-driver/mysql exposes the error number as a field, which is read without depending on the driver.
+	number, ok := errorField(err, "Number")
+	if !ok || !number.CanUint() {
+		return false
+	}
+	// 1205 is ER_LOCK_WAIT_TIMEOUT and 1213 is ER_LOCK_DEADLOCK.
+	return number.Uint() == 1205 || number.Uint() == 1213
+}
+
+func (dialect MysqlDialect) LockMigrations(ctx context.Context, db *sql.DB, timeout time.Duration) (func() error, error) {
+	// Named locks are held by a dedicated connection.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seconds := -1
+	if timeout > 0 {
+		seconds = int(math.Ceil(timeout.Seconds()))
+	}
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", migrationLockName, seconds).Scan(&locked); err != nil {
+		return nil, errors.Join(err, conn.Close())
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		return nil, errors.Join(rem.ErrMigrationLockTimeout, conn.Close())
+	}
+	return func() error {
+		var released sql.NullInt64
+		err := conn.QueryRowContext(context.Background(), "SELECT RELEASE_LOCK(?)", migrationLockName).Scan(&released)
+		return errors.Join(err, conn.Close())
+	}, nil
+}
+
+func (dialect MysqlDialect) parseColumnType(definition string) (string, bool) {
+	upper := strings.ToUpper(definition)
+	end := len(definition)
+	for _, keyword := range []string{" PRIMARY KEY", " NOT NULL", " NULL", " REFERENCES ", " DEFAULT ", " UNIQUE", " AUTO_INCREMENT"} {
+		if i := strings.Index(upper, keyword); i >= 0 && i < end {
+			end = i
+		}
+	}
+	nullable := !strings.Contains(upper, " NOT NULL") && !strings.Contains(upper, " PRIMARY KEY")
+	return strings.TrimSpace(definition[:end]), nullable
+}
+
 func (dialect MysqlDialect) Param(identifier int) string {
 	return "?"
 }
 
+func (dialect MysqlDialect) ParamLimit() int {
+	return 65535
+}
+
 func (dialect MysqlDialect) QuoteIdentifier(identifier string) string {
 	var query strings.Builder
 	for i, part := range strings.Split(identifier, ".") {
@@ -511,6 +1097,14 @@ func (dialect MysqlDialect) QuoteIdentifier(identifier string) string {
 	return query.String()
 }
 
+func (dialect MysqlDialect) SupportsReturning() bool {
+	return false
+}
+
+func (dialect MysqlDialect) SupportsTransactionalDDL() bool {
+	return false
+}
+
 func QuoteIdentifier(identifier string) string {
 	return "`" + strings.Replace(identifier, "`", "``", -1) + "`"
 }
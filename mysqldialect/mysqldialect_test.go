@@ -1,11 +1,16 @@
 package mysqldialect
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
 	"sort"
 	"testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/evantbyrne/rem"
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
@@ -151,6 +156,54 @@ func TestBuildInsert(t *testing.T) {
 	if !slices.Equal(args, expectedArgs) {
 		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
 	}
+
+	// RETURNING
+	config.Returning = []string{"test_id"}
+	_, _, err = dialect.BuildInsert(config, map[string]interface{}{
+		"test_value_1": "foo",
+		"test_value_2": "bar",
+	}, "test_value_1", "test_value_2")
+	if err == nil {
+		t.Error("Expected error for RETURNING")
+	}
+}
+
+func TestBuildInsertMany(t *testing.T) {
+	type testModel struct {
+		Id     int64  `db:"test_id" db_primary:"true"`
+		Value1 string `db:"test_value_1" db_max_length:"100"`
+		Value2 string `db:"test_value_2" db_max_length:"100"`
+	}
+
+	dialect := MysqlDialect{}
+	model := rem.Use[testModel]()
+
+	config := model.Query().Config
+	config.Fields = model.Fields
+	config.Table = "testmodel"
+	expectedArgs := []interface{}{"foo", "bar", "baz", "qux"}
+	expectedSql := "INSERT INTO `testmodel` (`test_value_1`,`test_value_2`) VALUES (?,?),(?,?)"
+	queryString, args, err := dialect.BuildInsertMany(config, []map[string]interface{}{
+		{"test_value_1": "foo", "test_value_2": "bar"},
+		{"test_value_1": "baz", "test_value_2": "qux"},
+	}, "test_value_1", "test_value_2")
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+	if !slices.Equal(args, expectedArgs) {
+		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
+	}
+
+	_, _, err = dialect.BuildInsertMany(config, []map[string]interface{}{
+		{"test_value_1": "foo", "test_value_2": "bar"},
+		{"test_value_1": "baz"},
+	}, "test_value_1", "test_value_2")
+	if err == nil {
+		t.Error("Expected error for missing column")
+	}
 }
 
 func TestBuildSelect(t *testing.T) {
@@ -265,6 +318,28 @@ func TestBuildSelect(t *testing.T) {
 		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
 	}
 
+	// GROUP BY and HAVING
+	config = model.Select("group_id", rem.As(rem.Sum("amount"), "total")).
+		Filter("id", ">", 1).
+		GroupBy("group_id").
+		Having(rem.Q(rem.Sum("amount"), ">", 100), rem.Q(rem.Count("*"), ">=", 2)).
+		Sort("group_id").
+		Config
+	config.Fields = model.Fields
+	config.Table = "testmodel"
+	expectedArgs = []interface{}{1, 100, 2}
+	expectedSql = "SELECT `group_id`,sum(`amount`) AS `total` FROM `testmodel` WHERE `id` > ? GROUP BY `group_id` HAVING ( sum(`amount`) > ? AND count(*) >= ? ) ORDER BY `group_id` ASC"
+	queryString, args, err = dialect.BuildSelect(config)
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+	if !slices.Equal(args, expectedArgs) {
+		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
+	}
+
 	// LIMIT and OFFSET
 	config = model.Filter("id", "=", 1).Offset(20).Limit(10).Config
 	config.Fields = model.Fields
@@ -281,6 +356,54 @@ func TestBuildSelect(t *testing.T) {
 	if !slices.Equal(args, expectedArgs) {
 		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
 	}
+
+	// FOR UPDATE
+	config = model.Filter("id", "=", 1).Limit(10).ForUpdate().SkipLocked().Config
+	config.Fields = model.Fields
+	config.Table = "testmodel"
+	expectedArgs = []interface{}{1, 10}
+	expectedSql = "SELECT * FROM `testmodel` WHERE `id` = ? LIMIT ? FOR UPDATE SKIP LOCKED"
+	queryString, args, err = dialect.BuildSelect(config)
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+	if !slices.Equal(args, expectedArgs) {
+		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
+	}
+
+	// FOR SHARE
+	config = model.ForShare().NoWait().Config
+	config.Fields = model.Fields
+	config.Table = "testmodel"
+	expectedSql = "SELECT * FROM `testmodel` FOR SHARE NOWAIT"
+	queryString, _, err = dialect.BuildSelect(config)
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+
+	config = model.Query().SkipLocked().Config
+	config.Fields = model.Fields
+	config.Table = "testmodel"
+	_, _, err = dialect.BuildSelect(config)
+	if err == nil || err.Error() != "rem: NOWAIT and SKIP LOCKED require FOR SHARE or FOR UPDATE" {
+		t.Errorf("Expected lock option error, got '%v'", err)
+	}
+
+	// Count
+	config = model.Query().ForUpdate().Config
+	config.Count = true
+	config.Fields = model.Fields
+	config.Table = "testmodel"
+	_, _, err = dialect.BuildSelect(config)
+	if err == nil || err.Error() != "rem: cannot lock rows when counting. Lock them with a separate query" {
+		t.Errorf("Expected count lock error, got '%v'", err)
+	}
 }
 
 func TestBuildTableColumnAdd(t *testing.T) {
@@ -304,6 +427,39 @@ func TestBuildTableColumnAdd(t *testing.T) {
 	}
 }
 
+func TestBuildTableColumnAlter(t *testing.T) {
+	type testModel struct {
+		Id    int64          `db:"test_id" db_primary:"true"`
+		Name  sql.NullString `db:"test_name" db_max_length:"100" db_default:"'x'"`
+		Value string         `db:"test_value" db_max_length:"100" db_unique:"true"`
+	}
+
+	dialect := MysqlDialect{}
+	model := rem.Use[testModel]()
+	config := rem.QueryConfig{
+		Fields: model.Fields,
+		Table:  "testmodel",
+	}
+	for column, expectedSql := range map[string]string{
+		"test_id":    "ALTER TABLE `testmodel` MODIFY COLUMN `test_id` BIGINT NOT NULL AUTO_INCREMENT",
+		"test_name":  "ALTER TABLE `testmodel` MODIFY COLUMN `test_name` VARCHAR(100) NULL DEFAULT 'x'",
+		"test_value": "ALTER TABLE `testmodel` MODIFY COLUMN `test_value` VARCHAR(100) NOT NULL",
+	} {
+		queryStrings, err := dialect.BuildTableColumnAlter(config, column)
+		if err != nil {
+			t.Errorf("Unexpected error %s", err.Error())
+		}
+		if len(queryStrings) != 1 || queryStrings[0] != expectedSql {
+			t.Errorf("Expected '%s', got '%s'", expectedSql, queryStrings)
+		}
+	}
+
+	_, err := dialect.BuildTableColumnAlter(config, "missing")
+	if err == nil || err.Error() != "rem: invalid column 'missing' on model for table 'testmodel'" {
+		t.Errorf("Expected invalid column error, got '%v'", err)
+	}
+}
+
 func TestBuildTableColumnDrop(t *testing.T) {
 	dialect := MysqlDialect{}
 	config := rem.QueryConfig{Table: "testmodel"}
@@ -317,6 +473,19 @@ func TestBuildTableColumnDrop(t *testing.T) {
 	}
 }
 
+func TestBuildTableColumnRename(t *testing.T) {
+	dialect := MysqlDialect{}
+	config := rem.QueryConfig{Table: "testmodel"}
+	expectedSql := "ALTER TABLE `testmodel` RENAME COLUMN `test_value` TO `value`"
+	queryString, err := dialect.BuildTableColumnRename(config, "test_value", "value")
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+}
+
 func TestBuildTableCreate(t *testing.T) {
 	type testModel struct {
 		Id     int64  `db:"test_id" db_primary:"true"`
@@ -354,6 +523,57 @@ func TestBuildTableCreate(t *testing.T) {
 	}
 }
 
+func TestBuildTableCreateComposite(t *testing.T) {
+	type testPairs struct {
+		A int64  `db:"a" db_primary:"true"`
+		B string `db:"b" db_primary:"true" db_max_length:"50"`
+	}
+	type testEvents struct {
+		Id   int64                     `db:"id" db_primary:"true"`
+		Pair rem.ForeignKey[testPairs] `db:"pair_a,pair_b" db_on_delete:"CASCADE"`
+	}
+
+	dialect := MysqlDialect{}
+	pairs := rem.Use[testPairs]()
+	config := rem.QueryConfig{
+		Fields:         pairs.Fields,
+		PrimaryColumns: pairs.PrimaryColumns,
+		Table:          pairs.Table,
+	}
+	expectedSql := "CREATE TABLE `testpairs` (\n" +
+		"\t`a` BIGINT NOT NULL,\n" +
+		"\t`b` VARCHAR(50) NOT NULL,\n" +
+		"\tPRIMARY KEY (`a`, `b`)\n" +
+		")"
+	queryString, err := dialect.BuildTableCreate(config, rem.TableCreateConfig{})
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+
+	events := rem.Use[testEvents]()
+	config = rem.QueryConfig{
+		Fields:         events.Fields,
+		PrimaryColumns: events.PrimaryColumns,
+		Table:          events.Table,
+	}
+	expectedSql = "CREATE TABLE `testevents` (\n" +
+		"\t`id` BIGINT PRIMARY KEY NOT NULL AUTO_INCREMENT,\n" +
+		"\t`pair_a` BIGINT NOT NULL,\n" +
+		"\t`pair_b` VARCHAR(50) NOT NULL,\n" +
+		"\tFOREIGN KEY (`pair_a`, `pair_b`) REFERENCES `testpairs` (`a`, `b`) ON DELETE CASCADE\n" +
+		")"
+	queryString, err = dialect.BuildTableCreate(config, rem.TableCreateConfig{})
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+}
+
 func TestBuildTableDrop(t *testing.T) {
 	dialect := MysqlDialect{}
 	config := rem.QueryConfig{Table: "testmodel"}
@@ -376,6 +596,97 @@ func TestBuildTableDrop(t *testing.T) {
 	}
 }
 
+func TestBuildTableIndexCreate(t *testing.T) {
+	type testModel struct {
+		Email string `db:"email" db_index:"testmodel_email_idx" db_index_unique:"true" db_index_where:"deleted_at IS NULL"`
+		Id    int64  `db:"id" db_primary:"true"`
+		Name  string `db:"name" db_index:"true,testmodel_pair_idx"`
+		Slug  string `db:"slug" db_index:"testmodel_pair_idx"`
+		Tags  string `db:"tags" db_index:"testmodel_tags_idx" db_index_method:"GIN"`
+	}
+
+	dialect := MysqlDialect{}
+	model := rem.Use[testModel]()
+	config := rem.QueryConfig{
+		Indexes: model.Indexes,
+		Table:   model.Table,
+	}
+
+	expectedSql := "CREATE INDEX `testmodel_name_idx` ON `testmodel` (`name`)"
+	queryString, err := dialect.BuildTableIndexCreate(config, "testmodel_name_idx", rem.TableIndexCreateConfig{})
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+
+	expectedSql = "CREATE INDEX `testmodel_tags_idx` USING GIN ON `testmodel` (`tags`)"
+	queryString, err = dialect.BuildTableIndexCreate(config, "testmodel_tags_idx", rem.TableIndexCreateConfig{})
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+
+	expectedSql = "CREATE INDEX `testmodel_pair_idx` ON `testmodel` (`name`, `slug`)"
+	queryString, err = dialect.BuildTableIndexCreate(config, "testmodel_pair_idx", rem.TableIndexCreateConfig{})
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+
+	_, err = dialect.BuildTableIndexCreate(config, "bogus", rem.TableIndexCreateConfig{})
+	if err == nil || err.Error() != "rem: invalid index 'bogus' on model for table 'testmodel'" {
+		t.Errorf("Expected error, got '%v'", err)
+	}
+
+	_, err = dialect.BuildTableIndexCreate(config, "testmodel_email_idx", rem.TableIndexCreateConfig{})
+	if err == nil || err.Error() != "rem: CREATE INDEX does not support WHERE" {
+		t.Errorf("Expected error, got '%v'", err)
+	}
+
+	_, err = dialect.BuildTableIndexCreate(config, "testmodel_name_idx", rem.TableIndexCreateConfig{IfNotExists: true})
+	if err == nil || err.Error() != "rem: CREATE INDEX does not support IF NOT EXISTS" {
+		t.Errorf("Expected error, got '%v'", err)
+	}
+}
+
+func TestBuildTableIndexDrop(t *testing.T) {
+	dialect := MysqlDialect{}
+	config := rem.QueryConfig{Table: "testmodel"}
+
+	expectedSql := "DROP INDEX `testmodel_name_idx` ON `testmodel`"
+	queryString, err := dialect.BuildTableIndexDrop(config, "testmodel_name_idx", rem.TableIndexDropConfig{})
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+
+	_, err = dialect.BuildTableIndexDrop(config, "testmodel_name_idx", rem.TableIndexDropConfig{IfExists: true})
+	if err == nil || err.Error() != "rem: DROP INDEX does not support IF EXISTS" {
+		t.Errorf("Expected error, got '%v'", err)
+	}
+}
+
+func TestBuildTableRename(t *testing.T) {
+	dialect := MysqlDialect{}
+	config := rem.QueryConfig{Table: "testmodel"}
+	expectedSql := "ALTER TABLE `testmodel` RENAME TO `renamed`"
+	queryString, err := dialect.BuildTableRename(config, "renamed")
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+}
+
 func TestBuildUpdate(t *testing.T) {
 	type testModel struct {
 		Id     int64  `db:"test_id" db_primary:"true"`
@@ -447,6 +758,96 @@ func TestBuildUpdate(t *testing.T) {
 	if !slices.Equal(args, expectedArgs) {
 		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
 	}
+
+	// RETURNING
+	config.Returning = []string{"test_id"}
+	_, _, err = dialect.BuildUpdate(config, map[string]interface{}{
+		"id":           123,
+		"test_value_1": "foo",
+		"test_value_2": "bar",
+	}, "test_value_1", "test_value_2")
+	if err == nil {
+		t.Error("Expected error for RETURNING")
+	}
+}
+
+func TestBuildUpsert(t *testing.T) {
+	type testModel struct {
+		Id     int64  `db:"test_id" db_primary:"true"`
+		Value1 string `db:"test_value_1" db_max_length:"100" db_unique:"true"`
+		Value2 string `db:"test_value_2" db_max_length:"100"`
+	}
+
+	dialect := MysqlDialect{}
+	model := rem.Use[testModel]()
+	rowMap := map[string]interface{}{
+		"test_value_1": "foo",
+		"test_value_2": "bar",
+	}
+
+	config := model.Query().Config
+	config.Fields = model.Fields
+	config.PrimaryColumns = model.PrimaryColumns
+	config.Table = "testmodel"
+	expectedArgs := []interface{}{"foo", "bar"}
+	expectedSql := "INSERT INTO `testmodel` (`test_value_1`,`test_value_2`) VALUES (?,?) AS new ON DUPLICATE KEY UPDATE `test_value_2` = new.`test_value_2`"
+	queryString, args, err := dialect.BuildUpsert(config, rowMap, rem.UpsertConfig{
+		ConflictColumns: []string{"test_value_1"},
+		UpdateColumns:   []string{"test_value_2"},
+	}, "test_value_1", "test_value_2")
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+	if !slices.Equal(args, expectedArgs) {
+		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
+	}
+
+	// DO NOTHING
+	expectedSql = "INSERT INTO `testmodel` (`test_value_1`,`test_value_2`) VALUES (?,?) ON DUPLICATE KEY UPDATE `test_value_1` = `test_value_1`"
+	queryString, args, err = dialect.BuildUpsert(config, rowMap, rem.UpsertConfig{
+		ConflictColumns: []string{"test_value_1"},
+		DoNothing:       true,
+	}, "test_value_1", "test_value_2")
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+	if !slices.Equal(args, expectedArgs) {
+		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
+	}
+
+	_, _, err = dialect.BuildUpsert(config, rowMap, rem.UpsertConfig{
+		ConflictColumns: []string{"test_value_1"},
+	}, "test_value_1", "test_value_2")
+	if err == nil {
+		t.Error("Expected error for missing update columns")
+	}
+
+	// Conflict columns that aren't a unique key.
+	_, _, err = dialect.BuildUpsert(config, rowMap, rem.UpsertConfig{
+		ConflictColumns: []string{"test_value_2"},
+		UpdateColumns:   []string{"test_value_1"},
+	}, "test_value_1", "test_value_2")
+	expectedErr := "rem: conflict columns 'test_value_2' are not a unique key on table 'testmodel'"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("Expected '%s', got '%v'", expectedErr, err)
+	}
+
+	// Inserting the primary key means the insert can conflict on either key.
+	rowMap["test_id"] = int64(1)
+	_, _, err = dialect.BuildUpsert(config, rowMap, rem.UpsertConfig{
+		ConflictColumns: []string{"test_value_1"},
+		UpdateColumns:   []string{"test_value_2"},
+	}, "test_id", "test_value_1", "test_value_2")
+	expectedErr = "rem: MySQL applies ON DUPLICATE KEY UPDATE to every unique key, so conflict columns 'test_value_1' can't be honored on table 'testmodel' with 2 unique keys"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("Expected '%s', got '%v'", expectedErr, err)
+	}
 }
 
 func TestColumnType(t *testing.T) {
@@ -529,6 +930,137 @@ func TestColumnType(t *testing.T) {
 			t.Fatalf(`dialect.ColumnType() returned '%s', but expected '%s' for '%#v'`, columnType, expected[fieldName], field)
 		}
 	}
+
+	// Composite primary keys are declared as a table constraint.
+	columnType, err := dialect.CompositePrimaryColumnType(model.Fields["test_id"])
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if columnType != "BIGINT NOT NULL" {
+		t.Errorf("Expected 'BIGINT NOT NULL', got '%s'", columnType)
+	}
+}
+
+func TestIntrospectTable(t *testing.T) {
+	dialect := MysqlDialect{}
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM information_schema.COLUMNS").
+		WithArgs("accounts").
+		WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME", "COLUMN_TYPE", "IS_NULLABLE", "COLUMN_DEFAULT", "EXTRA"}).
+			AddRow("id", "bigint", "NO", nil, "auto_increment").
+			AddRow("name", "varchar(100)", "NO", "foo", "").
+			AddRow("group_a", "bigint", "YES", nil, "").
+			AddRow("group_b", "text", "YES", nil, ""))
+	mock.ExpectQuery("CONSTRAINT_NAME = 'PRIMARY'").
+		WithArgs("accounts").
+		WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}).
+			AddRow("id"))
+	mock.ExpectQuery("REFERENTIAL_CONSTRAINTS").
+		WithArgs("accounts").
+		WillReturnRows(sqlmock.NewRows([]string{"CONSTRAINT_NAME", "COLUMN_NAME", "REFERENCED_TABLE_NAME", "REFERENCED_COLUMN_NAME", "UPDATE_RULE", "DELETE_RULE"}).
+			AddRow("accounts_group_fkey", "group_a", "groups", "a", "NO ACTION", "CASCADE").
+			AddRow("accounts_group_fkey", "group_b", "groups", "b", "NO ACTION", "CASCADE"))
+	mock.ExpectQuery("FROM information_schema.STATISTICS").
+		WithArgs("accounts").
+		WillReturnRows(sqlmock.NewRows([]string{"INDEX_NAME", "NON_UNIQUE", "INDEX_TYPE", "COLUMN_NAME"}).
+			AddRow("accounts_group_idx", 1, "BTREE", "group_a").
+			AddRow("accounts_group_idx", 1, "BTREE", "group_b").
+			AddRow("accounts_name_idx", 0, "HASH", "name"))
+	schema, err := dialect.IntrospectTable(context.Background(), db, "accounts")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	expected := &rem.TableSchema{
+		Columns: []rem.ColumnSchema{
+			{AutoIncrement: true, Name: "id", Type: "BIGINT"},
+			{Default: sql.NullString{String: "foo", Valid: true}, Name: "name", Type: "VARCHAR(100)"},
+			{Name: "group_a", Nullable: true, Type: "BIGINT"},
+			{Name: "group_b", Nullable: true, Type: "TEXT"},
+		},
+		ForeignKeys: []rem.ForeignKeySchema{
+			{Columns: []string{"group_a", "group_b"}, Name: "accounts_group_fkey", OnDelete: "CASCADE", OnUpdate: "NO ACTION", ReferencedColumns: []string{"a", "b"}, ReferencedTable: "groups"},
+		},
+		Indexes: []rem.Index{
+			{Columns: []string{"group_a", "group_b"}, Name: "accounts_group_idx"},
+			{Columns: []string{"name"}, Method: "HASH", Name: "accounts_name_idx", Unique: true},
+		},
+		Name:       "accounts",
+		PrimaryKey: []string{"id"},
+	}
+	if !reflect.DeepEqual(schema, expected) {
+		t.Errorf("Expected '%+v', got '%+v'", expected, schema)
+	}
+
+	mock.ExpectQuery("information_schema.COLUMNS").
+		WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}))
+	if _, err := dialect.IntrospectTable(context.Background(), db, "missing"); !errors.Is(err, rem.ErrTableNotExist) {
+		t.Errorf("Expected missing table error, got '%v'", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+type testMysqlError struct {
+	Number uint16
+}
+
+func (err *testMysqlError) Error() string {
+	return fmt.Sprintf("Error %d", err.Number)
+}
+
+func TestIsRetryableError(t *testing.T) {
+	dialect := MysqlDialect{}
+	expected := map[error]bool{
+		&testMysqlError{Number: 1213}:                               true,
+		fmt.Errorf("wrapped: %w", &testMysqlError{Number: 1213}):    true,
+		&testMysqlError{Number: 1205}:                               true,
+		errors.Join(errors.New("a"), &testMysqlError{Number: 1213}): true,
+		&testMysqlError{Number: 1062}:                               false,
+		errors.New("Error 1213"):                                    false,
+	}
+	for err, retryable := range expected {
+		if dialect.IsRetryableError(err) != retryable {
+			t.Errorf("Expected '%t' for '%v'", retryable, err)
+		}
+	}
+}
+
+func TestLockMigrations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT GET_LOCK\\(\\?, \\?\\)").WithArgs(migrationLockName, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(1))
+	mock.ExpectQuery("SELECT RELEASE_LOCK\\(\\?\\)").WithArgs(migrationLockName).
+		WillReturnRows(sqlmock.NewRows([]string{"released"}).AddRow(1))
+	unlock, err := MysqlDialect{}.LockMigrations(context.Background(), db, 1500*time.Millisecond)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err := unlock(); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	mock.ExpectQuery("SELECT GET_LOCK\\(\\?, \\?\\)").WithArgs(migrationLockName, -1).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(0))
+	_, err = MysqlDialect{}.LockMigrations(context.Background(), db, 0)
+	if !errors.Is(err, rem.ErrMigrationLockTimeout) {
+		t.Errorf("Expected rem.ErrMigrationLockTimeout, got '%v'", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
 }
 
 func TestQuoteIdentifier(t *testing.T) {
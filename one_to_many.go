@@ -1,8 +1,8 @@
 package rem
 
 import (
-	"database/sql"
 	"encoding/json"
+	"reflect"
 )
 
 type OneToMany[To any] struct {
@@ -11,7 +11,7 @@ type OneToMany[To any] struct {
 	Rows          []*To
 }
 
-func (field *OneToMany[To]) All(db *sql.DB) ([]*To, error) {
+func (field *OneToMany[To]) All(db Executor) ([]*To, error) {
 	return field.Query().Filter(field.RelatedColumn, "=", field.RowPk).All(db)
 }
 
@@ -42,3 +42,11 @@ func (field *OneToMany[To]) Query() *Query[To] {
 		Model: Use[To](),
 	}
 }
+
+func (field *OneToMany[To]) relatedType() reflect.Type {
+	return reflect.TypeOf((*To)(nil)).Elem()
+}
+
+func (field *OneToMany[To]) relationKind() fieldKind {
+	return fieldOneToMany
+}
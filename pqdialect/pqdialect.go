@@ -1,7 +1,9 @@
 package pqdialect
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"reflect"
 	"sort"
@@ -15,6 +17,8 @@ import (
 
 type PqDialect struct{}
 
+const migrationLockKey int64 = 0x72656d6d6967
+
 func (dialect PqDialect) BuildDelete(config rem.QueryConfig) (string, []interface{}, error) {
 	args := append([]interface{}(nil), config.Params...)
 	var queryString strings.Builder
@@ -49,42 +53,72 @@ func (dialect PqDialect) BuildDelete(config rem.QueryConfig) (string, []interfac
 }
 
 func (dialect PqDialect) BuildInsert(config rem.QueryConfig, rowMap map[string]interface{}, columns ...string) (string, []interface{}, error) {
+	return dialect.BuildInsertMany(config, []map[string]interface{}{rowMap}, columns...)
+}
+
+func (dialect PqDialect) BuildInsertMany(config rem.QueryConfig, rowMaps []map[string]interface{}, columns ...string) (string, []interface{}, error) {
 	args := make([]interface{}, 0)
 	var queryString strings.Builder
 
+	if len(rowMaps) == 0 {
+		return "", nil, fmt.Errorf("rem: no rows specified for INSERT")
+	}
+
 	queryString.WriteString("INSERT INTO ")
 	queryString.WriteString(dialect.QuoteIdentifier(config.Table))
 	queryString.WriteString(" (")
-	first := true
-	for _, column := range columns {
-		if arg, ok := rowMap[column]; ok {
-			if _, ok := config.Fields[column]; !ok {
-				return "", nil, fmt.Errorf("rem: field for column '%s' not found on model for table '%s'", column, config.Table)
+	for i, column := range columns {
+		if _, ok := config.Fields[column]; !ok {
+			return "", nil, fmt.Errorf("rem: field for column '%s' not found on model for table '%s'", column, config.Table)
+		}
+		if i > 0 {
+			queryString.WriteString(",")
+		}
+		queryString.WriteString(dialect.QuoteIdentifier(column))
+	}
+
+	queryString.WriteString(") VALUES ")
+	for i, rowMap := range rowMaps {
+		if i > 0 {
+			queryString.WriteString(",")
+		}
+		queryString.WriteString("(")
+		for j, column := range columns {
+			arg, ok := rowMap[column]
+			if !ok {
+				return "", nil, fmt.Errorf("rem: invalid column '%s' on INSERT", column)
 			}
 			args = append(args, arg)
-			if first {
-				first = false
-			} else {
+			if j > 0 {
 				queryString.WriteString(",")
 			}
-			queryString.WriteString(dialect.QuoteIdentifier(column))
-		} else {
-			return "", nil, fmt.Errorf("rem: invalid column '%s' on INSERT", column)
+			queryString.WriteString(dialect.Param(len(args)))
 		}
+		queryString.WriteString(")")
 	}
 
-	queryString.WriteString(") VALUES (")
-	for i := 1; i <= len(rowMap); i++ {
-		if i > 1 {
-			queryString.WriteString(",")
-		}
-		queryString.WriteString(dialect.Param(i))
-	}
-	queryString.WriteString(")")
+	// RETURNING
+	queryString.WriteString(dialect.buildReturning(config))
 
 	return queryString.String(), args, nil
 }
 
+func (dialect PqDialect) buildHaving(config rem.QueryConfig, args []interface{}) (string, []interface{}, error) {
+	var queryPart strings.Builder
+	if len(config.Having) > 0 {
+		queryPart.WriteString(" HAVING")
+		for _, where := range config.Having {
+			queryWhere, whereArgs, err := where.StringWithArgs(dialect, args)
+			if err != nil {
+				return "", nil, err
+			}
+			args = whereArgs
+			queryPart.WriteString(queryWhere)
+		}
+	}
+	return queryPart.String(), args, nil
+}
+
 func (dialect PqDialect) buildJoins(config rem.QueryConfig, args []interface{}) (string, []interface{}, error) {
 	var queryPart strings.Builder
 	if len(config.Joins) > 0 {
@@ -105,6 +139,48 @@ func (dialect PqDialect) buildJoins(config rem.QueryConfig, args []interface{})
 	return queryPart.String(), args, nil
 }
 
+func (dialect PqDialect) buildLock(config rem.QueryConfig) (string, error) {
+	if config.Count && (config.ForShare || config.ForUpdate || config.NoWait || config.SkipLocked) {
+		return "", fmt.Errorf("rem: cannot lock rows when counting. Lock them with a separate query")
+	}
+	if config.ForShare && config.ForUpdate {
+		return "", fmt.Errorf("rem: cannot lock rows for both SHARE and UPDATE")
+	}
+	if config.NoWait && config.SkipLocked {
+		return "", fmt.Errorf("rem: cannot use both NOWAIT and SKIP LOCKED")
+	}
+
+	var queryPart strings.Builder
+	if config.ForShare {
+		queryPart.WriteString(" FOR SHARE")
+	} else if config.ForUpdate {
+		queryPart.WriteString(" FOR UPDATE")
+	} else if config.NoWait || config.SkipLocked {
+		return "", fmt.Errorf("rem: NOWAIT and SKIP LOCKED require FOR SHARE or FOR UPDATE")
+	}
+
+	if config.NoWait {
+		queryPart.WriteString(" NOWAIT")
+	} else if config.SkipLocked {
+		queryPart.WriteString(" SKIP LOCKED")
+	}
+	return queryPart.String(), nil
+}
+
+func (dialect PqDialect) buildReturning(config rem.QueryConfig) string {
+	var queryPart strings.Builder
+	if len(config.Returning) > 0 {
+		queryPart.WriteString(" RETURNING ")
+		for i, column := range config.Returning {
+			if i > 0 {
+				queryPart.WriteString(",")
+			}
+			queryPart.WriteString(dialect.QuoteIdentifier(column))
+		}
+	}
+	return queryPart.String()
+}
+
 func (dialect PqDialect) BuildSelect(config rem.QueryConfig) (string, []interface{}, error) {
 	args := append([]interface{}(nil), config.Params...)
 	var queryString strings.Builder
@@ -154,6 +230,38 @@ func (dialect PqDialect) BuildSelect(config rem.QueryConfig) (string, []interfac
 		queryString.WriteString(where)
 	}
 
+	// GROUP BY
+	if len(config.GroupBy) > 0 {
+		queryString.WriteString(" GROUP BY ")
+		for i, column := range config.GroupBy {
+			if i > 0 {
+				queryString.WriteString(",")
+			}
+			switch cv := column.(type) {
+			case string:
+				queryString.WriteString(dialect.QuoteIdentifier(cv))
+
+			case rem.DialectStringer:
+				queryString.WriteString(cv.StringForDialect(dialect))
+
+			case fmt.Stringer:
+				queryString.WriteString(cv.String())
+
+			default:
+				return "", nil, fmt.Errorf("rem: invalid column type %#v", column)
+			}
+		}
+	}
+
+	// HAVING
+	having, args, err := dialect.buildHaving(config, args)
+	if err != nil {
+		return "", nil, err
+	}
+	if having != "" {
+		queryString.WriteString(having)
+	}
+
 	// ORDER BY
 	if len(config.Sort) > 0 {
 		queryString.WriteString(" ORDER BY ")
@@ -185,6 +293,13 @@ func (dialect PqDialect) BuildSelect(config rem.QueryConfig) (string, []interfac
 		queryString.WriteString(dialect.Param(len(args)))
 	}
 
+	// FOR UPDATE, FOR SHARE
+	lock, err := dialect.buildLock(config)
+	if err != nil {
+		return "", nil, err
+	}
+	queryString.WriteString(lock)
+
 	return queryString.String(), args, nil
 }
 
@@ -194,17 +309,64 @@ func (dialect PqDialect) BuildTableColumnAdd(config rem.QueryConfig, column stri
 		return "", fmt.Errorf("rem: invalid column '%s' on model for table '%s'", column, config.Table)
 	}
 
-	columnType, err := dialect.ColumnType(field)
+	columnType, err := dialect.columnType(field, len(config.PrimaryColumns) > 1)
 	if err != nil {
 		return "", err
 	}
 	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", dialect.QuoteIdentifier(config.Table), dialect.QuoteIdentifier(column), columnType), nil
 }
 
+func (dialect PqDialect) BuildTableColumnAlter(config rem.QueryConfig, column string) ([]string, error) {
+	field, ok := config.Fields[column]
+	if !ok {
+		return nil, fmt.Errorf("rem: invalid column '%s' on model for table '%s'", column, config.Table)
+	}
+	definition, err := dialect.columnType(field, len(config.PrimaryColumns) > 1)
+	if err != nil {
+		return nil, err
+	}
+	columnType, nullable := dialect.parseColumnType(definition)
+	quotedColumn := dialect.QuoteIdentifier(column)
+	var sql strings.Builder
+	sql.WriteString("ALTER TABLE ")
+	sql.WriteString(dialect.QuoteIdentifier(config.Table))
+
+	// Serial types are integers with a sequence default, which is left as-is.
+	serial := true
+	switch strings.ToUpper(columnType) {
+	case "BIGSERIAL":
+		columnType = "BIGINT"
+	case "SERIAL":
+		columnType = "INTEGER"
+	case "SMALLSERIAL":
+		columnType = "SMALLINT"
+	default:
+		serial = false
+	}
+	sql.WriteString(fmt.Sprintf(" ALTER COLUMN %s TYPE %s USING %s::%s", quotedColumn, columnType, quotedColumn, columnType))
+
+	if nullable {
+		sql.WriteString(fmt.Sprintf(", ALTER COLUMN %s DROP NOT NULL", quotedColumn))
+	} else {
+		sql.WriteString(fmt.Sprintf(", ALTER COLUMN %s SET NOT NULL", quotedColumn))
+	}
+
+	if tagDefault := field.Tag.Get("db_default"); tagDefault != "" {
+		sql.WriteString(fmt.Sprintf(", ALTER COLUMN %s SET DEFAULT %s", quotedColumn, tagDefault))
+	} else if !serial {
+		sql.WriteString(fmt.Sprintf(", ALTER COLUMN %s DROP DEFAULT", quotedColumn))
+	}
+	return []string{sql.String()}, nil
+}
+
 func (dialect PqDialect) BuildTableColumnDrop(config rem.QueryConfig, column string) (string, error) {
 	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", dialect.QuoteIdentifier(config.Table), dialect.QuoteIdentifier(column)), nil
 }
 
+func (dialect PqDialect) BuildTableColumnRename(config rem.QueryConfig, from string, to string) (string, error) {
+	return fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", dialect.QuoteIdentifier(config.Table), dialect.QuoteIdentifier(from), dialect.QuoteIdentifier(to)), nil
+}
+
 func (dialect PqDialect) BuildTableCreate(config rem.QueryConfig, tableCreateConfig rem.TableCreateConfig) (string, error) {
 	var sql strings.Builder
 	sql.WriteString("CREATE TABLE ")
@@ -217,7 +379,8 @@ func (dialect PqDialect) BuildTableCreate(config rem.QueryConfig, tableCreateCon
 	sort.Strings(fieldNames)
 	for i, fieldName := range fieldNames {
 		field := config.Fields[fieldName]
-		columnType, err := dialect.ColumnType(field)
+		// Composite primary keys are declared as a table constraint.
+		columnType, err := dialect.columnType(field, len(config.PrimaryColumns) > 1)
 		if err != nil {
 			return "", err
 		}
@@ -229,10 +392,73 @@ func (dialect PqDialect) BuildTableCreate(config rem.QueryConfig, tableCreateCon
 		sql.WriteString(" ")
 		sql.WriteString(columnType)
 	}
+	constraints, err := dialect.buildTableConstraints(config)
+	if err != nil {
+		return "", err
+	}
+	sql.WriteString(constraints)
 	sql.WriteString("\n)")
 	return sql.String(), nil
 }
 
+func (dialect PqDialect) buildTableConstraints(config rem.QueryConfig) (string, error) {
+	var sql strings.Builder
+	if len(config.PrimaryColumns) > 1 {
+		sql.WriteString(",\n\tPRIMARY KEY (")
+		for i, column := range config.PrimaryColumns {
+			if i > 0 {
+				sql.WriteString(", ")
+			}
+			sql.WriteString(dialect.QuoteIdentifier(column))
+		}
+		sql.WriteString(")")
+	}
+
+	// Composite foreign keys are grouped by struct field.
+	foreignKeys := make(map[string]map[string]string)
+	foreignKeyFields := make(map[string]reflect.StructField)
+	for column, field := range config.Fields {
+		if references, ok := field.Tag.Lookup("db_references"); ok {
+			if _, ok := foreignKeys[field.Name]; !ok {
+				foreignKeys[field.Name] = make(map[string]string)
+				foreignKeyFields[field.Name] = field
+			}
+			foreignKeys[field.Name][references] = column
+		}
+	}
+	fieldNames := maps.Keys(foreignKeys)
+	sort.Strings(fieldNames)
+	for _, fieldName := range fieldNames {
+		field := foreignKeyFields[fieldName]
+		subModelQ := reflect.New(field.Type).MethodByName("Model").Call(nil)
+		subPrimaryColumns := reflect.Indirect(subModelQ[0]).FieldByName("PrimaryColumns").Interface().([]string)
+		subTable := reflect.Indirect(subModelQ[0]).FieldByName("Table").Interface().(string)
+
+		columns := make([]string, len(subPrimaryColumns))
+		references := make([]string, len(subPrimaryColumns))
+		for i, subPrimaryColumn := range subPrimaryColumns {
+			column, ok := foreignKeys[fieldName][subPrimaryColumn]
+			if !ok {
+				return "", fmt.Errorf("rem: foreign key field '%s' is missing a column for '%s' on table '%s'", fieldName, subPrimaryColumn, subTable)
+			}
+			columns[i] = dialect.QuoteIdentifier(column)
+			references[i] = dialect.QuoteIdentifier(subPrimaryColumn)
+		}
+		sql.WriteString(fmt.Sprintf(",\n\tFOREIGN KEY (%s) REFERENCES %s (%s)", strings.Join(columns, ", "), dialect.QuoteIdentifier(subTable), strings.Join(references, ", ")))
+
+		if tagOnUpdate := field.Tag.Get("db_on_update"); tagOnUpdate != "" {
+			// ON UPDATE.
+			sql.WriteString(fmt.Sprint(" ON UPDATE ", tagOnUpdate))
+		}
+
+		if tagOnDelete := field.Tag.Get("db_on_delete"); tagOnDelete != "" {
+			// ON DELETE.
+			sql.WriteString(fmt.Sprint(" ON DELETE ", tagOnDelete))
+		}
+	}
+	return sql.String(), nil
+}
+
 func (dialect PqDialect) BuildTableDrop(config rem.QueryConfig, tableDropConfig rem.TableDropConfig) (string, error) {
 	var queryString strings.Builder
 	queryString.WriteString("DROP TABLE ")
@@ -243,6 +469,66 @@ func (dialect PqDialect) BuildTableDrop(config rem.QueryConfig, tableDropConfig
 	return queryString.String(), nil
 }
 
+func (dialect PqDialect) BuildTableIndexCreate(config rem.QueryConfig, name string, tableIndexCreateConfig rem.TableIndexCreateConfig) (string, error) {
+	var index *rem.Index
+	for i := range config.Indexes {
+		if config.Indexes[i].Name == name {
+			index = &config.Indexes[i]
+			break
+		}
+	}
+	if index == nil {
+		return "", fmt.Errorf("rem: invalid index '%s' on model for table '%s'", name, config.Table)
+	}
+	if len(index.Columns) == 0 {
+		return "", fmt.Errorf("rem: index '%s' on table '%s' has no columns", name, config.Table)
+	}
+
+	var sql strings.Builder
+	sql.WriteString("CREATE ")
+	if index.Unique {
+		sql.WriteString("UNIQUE ")
+	}
+	sql.WriteString("INDEX ")
+	if tableIndexCreateConfig.IfNotExists {
+		sql.WriteString("IF NOT EXISTS ")
+	}
+	sql.WriteString(dialect.QuoteIdentifier(index.Name))
+	sql.WriteString(" ON ")
+	sql.WriteString(dialect.QuoteIdentifier(config.Table))
+	if index.Method != "" {
+		sql.WriteString(" USING ")
+		sql.WriteString(index.Method)
+	}
+	sql.WriteString(" (")
+	for i, column := range index.Columns {
+		if i > 0 {
+			sql.WriteString(", ")
+		}
+		sql.WriteString(dialect.QuoteIdentifier(column))
+	}
+	sql.WriteString(")")
+	if index.Where != "" {
+		sql.WriteString(" WHERE ")
+		sql.WriteString(index.Where)
+	}
+	return sql.String(), nil
+}
+
+func (dialect PqDialect) BuildTableIndexDrop(config rem.QueryConfig, name string, tableIndexDropConfig rem.TableIndexDropConfig) (string, error) {
+	var sql strings.Builder
+	sql.WriteString("DROP INDEX ")
+	if tableIndexDropConfig.IfExists {
+		sql.WriteString("IF EXISTS ")
+	}
+	sql.WriteString(dialect.QuoteIdentifier(name))
+	return sql.String(), nil
+}
+
+func (dialect PqDialect) BuildTableRename(config rem.QueryConfig, name string) (string, error) {
+	return fmt.Sprintf("ALTER TABLE %s RENAME TO %s", dialect.QuoteIdentifier(config.Table), dialect.QuoteIdentifier(name)), nil
+}
+
 func (dialect PqDialect) BuildUpdate(config rem.QueryConfig, rowMap map[string]interface{}, columns ...string) (string, []interface{}, error) {
 	args := append([]interface{}(nil), config.Params...)
 	var queryString strings.Builder
@@ -277,9 +563,63 @@ func (dialect PqDialect) BuildUpdate(config rem.QueryConfig, rowMap map[string]i
 		queryString.WriteString(where)
 	}
 
+	// RETURNING
+	queryString.WriteString(dialect.buildReturning(config))
+
 	return queryString.String(), args, nil
 }
 
+func (dialect PqDialect) BuildUpsert(config rem.QueryConfig, rowMap map[string]interface{}, upsertConfig rem.UpsertConfig, columns ...string) (string, []interface{}, error) {
+	insertConfig := config
+	insertConfig.Returning = nil
+	queryString, args, err := dialect.BuildInsert(insertConfig, rowMap, columns...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var sql strings.Builder
+	sql.WriteString(queryString)
+	sql.WriteString(" ON CONFLICT")
+	if len(upsertConfig.ConflictColumns) > 0 {
+		sql.WriteString(" (")
+		for i, column := range upsertConfig.ConflictColumns {
+			if i > 0 {
+				sql.WriteString(",")
+			}
+			sql.WriteString(dialect.QuoteIdentifier(column))
+		}
+		sql.WriteString(")")
+	}
+
+	if upsertConfig.DoNothing {
+		sql.WriteString(" DO NOTHING")
+	} else {
+		if len(upsertConfig.ConflictColumns) == 0 {
+			return "", nil, fmt.Errorf("rem: no conflict columns specified for ON CONFLICT DO UPDATE")
+		}
+		if len(upsertConfig.UpdateColumns) == 0 {
+			return "", nil, fmt.Errorf("rem: no columns specified for ON CONFLICT DO UPDATE")
+		}
+		sql.WriteString(" DO UPDATE SET ")
+		for i, column := range upsertConfig.UpdateColumns {
+			if _, ok := config.Fields[column]; !ok {
+				return "", nil, fmt.Errorf("rem: invalid column '%s' on ON CONFLICT DO UPDATE", column)
+			}
+			if i > 0 {
+				sql.WriteString(",")
+			}
+			sql.WriteString(dialect.QuoteIdentifier(column))
+			sql.WriteString(" = EXCLUDED.")
+			sql.WriteString(dialect.QuoteIdentifier(column))
+		}
+	}
+
+	// RETURNING
+	sql.WriteString(dialect.buildReturning(config))
+
+	return sql.String(), args, nil
+}
+
 func (dialect PqDialect) buildWhere(config rem.QueryConfig, args []interface{}) (string, []interface{}, error) {
 	var queryPart strings.Builder
 	if len(config.Filters) > 0 {
@@ -297,6 +637,10 @@ func (dialect PqDialect) buildWhere(config rem.QueryConfig, args []interface{})
 }
 
 func (dialect PqDialect) ColumnType(field reflect.StructField) (string, error) {
+	return dialect.columnType(field, false)
+}
+
+func (dialect PqDialect) columnType(field reflect.StructField, compositePrimary bool) (string, error) {
 	tagType := field.Tag.Get("db_type")
 	if tagType != "" {
 		return tagType, nil
@@ -307,7 +651,7 @@ func (dialect PqDialect) ColumnType(field reflect.StructField) (string, error) {
 	var columnPrimary string
 	var columnType string
 
-	if field.Tag.Get("db_primary") == "true" {
+	if field.Tag.Get("db_primary") == "true" && !compositePrimary {
 		columnPrimary = " PRIMARY KEY"
 
 		switch fieldInstance.(type) {
@@ -406,8 +750,11 @@ func (dialect PqDialect) ColumnType(field reflect.StructField) (string, error) {
 				subModelQ := fv.Addr().MethodByName("Model").Call(nil)
 				subFields := reflect.Indirect(subModelQ[0]).FieldByName("Fields").Interface().(map[string]reflect.StructField)
 				subPrimaryColumn := reflect.Indirect(subModelQ[0]).FieldByName("PrimaryColumn").Interface().(string)
+				if references, ok := field.Tag.Lookup("db_references"); ok {
+					subPrimaryColumn = references
+				}
 				subTable := reflect.Indirect(subModelQ[0]).FieldByName("Table").Interface().(string)
-				columnTypeTemp, err := dialect.ColumnType(subFields[subPrimaryColumn])
+				columnTypeTemp, err := dialect.columnType(subFields[subPrimaryColumn], false)
 				if err != nil {
 					return "", err
 				}
@@ -420,16 +767,19 @@ func (dialect PqDialect) ColumnType(field reflect.StructField) (string, error) {
 				if strings.HasPrefix(field.Type.String(), "rem.NullForeignKey[") {
 					columnNull = " NULL"
 				}
-				columnNull = fmt.Sprintf("%s REFERENCES %s (%s)", columnNull, dialect.QuoteIdentifier(subTable), dialect.QuoteIdentifier(subPrimaryColumn))
+				// Composite foreign keys are declared as a table constraint.
+				if _, ok := field.Tag.Lookup("db_references"); !ok {
+					columnNull = fmt.Sprintf("%s REFERENCES %s (%s)", columnNull, dialect.QuoteIdentifier(subTable), dialect.QuoteIdentifier(subPrimaryColumn))
 
-				if tagOnUpdate := field.Tag.Get("db_on_update"); tagOnUpdate != "" {
-					// ON UPDATE.
-					columnNull = fmt.Sprint(columnNull, " ON UPDATE ", tagOnUpdate)
-				}
+					if tagOnUpdate := field.Tag.Get("db_on_update"); tagOnUpdate != "" {
+						// ON UPDATE.
+						columnNull = fmt.Sprint(columnNull, " ON UPDATE ", tagOnUpdate)
+					}
 
-				if tagOnDelete := field.Tag.Get("db_on_delete"); tagOnDelete != "" {
-					// ON DELETE.
-					columnNull = fmt.Sprint(columnNull, " ON DELETE ", tagOnDelete)
+					if tagOnDelete := field.Tag.Get("db_on_delete"); tagOnDelete != "" {
+						// ON DELETE.
+						columnNull = fmt.Sprint(columnNull, " ON DELETE ", tagOnDelete)
+					}
 				}
 			}
 		}
@@ -452,6 +802,238 @@ func (dialect PqDialect) ColumnType(field reflect.StructField) (string, error) {
 	return fmt.Sprint(columnType, columnPrimary, columnNull), nil
 }
 
+func (dialect PqDialect) CompositePrimaryColumnType(field reflect.StructField) (string, error) {
+	return dialect.columnType(field, true)
+}
+
+func (dialect PqDialect) introspectColumns(ctx context.Context, db rem.Executor, table string) ([]rem.ColumnSchema, error) {
+	rows, err := db.QueryContext(ctx, `SELECT column_name, data_type, character_maximum_length, is_nullable, column_default, is_identity
+FROM information_schema.columns
+WHERE table_schema = current_schema() AND table_name = $1
+ORDER BY ordinal_position`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make([]rem.ColumnSchema, 0)
+	for rows.Next() {
+		var column rem.ColumnSchema
+		var dataType string
+		var isIdentity string
+		var isNullable string
+		var maxLength sql.NullInt64
+		if err := rows.Scan(&column.Name, &dataType, &maxLength, &isNullable, &column.Default, &isIdentity); err != nil {
+			return nil, err
+		}
+
+		column.Type = strings.ToUpper(dataType)
+		switch column.Type {
+		case "CHARACTER VARYING":
+			column.Type = "VARCHAR"
+		case "CHARACTER":
+			column.Type = "CHAR"
+		}
+		if maxLength.Valid {
+			column.Type = fmt.Sprintf("%s(%d)", column.Type, maxLength.Int64)
+		}
+		column.Nullable = isNullable == "YES"
+		if isIdentity == "YES" || strings.HasPrefix(column.Default.String, "nextval(") {
+			column.AutoIncrement = true
+			column.Default = sql.NullString{}
+		}
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
+
+func (dialect PqDialect) introspectForeignKeys(ctx context.Context, db rem.Executor, table string) ([]rem.ForeignKeySchema, error) {
+	rows, err := db.QueryContext(ctx, `SELECT tc.constraint_name, kcu.column_name, ccu.table_name, ccu.column_name, rc.update_rule, rc.delete_rule
+FROM information_schema.table_constraints tc
+JOIN information_schema.key_column_usage kcu ON kcu.constraint_schema = tc.constraint_schema AND kcu.constraint_name = tc.constraint_name
+JOIN information_schema.referential_constraints rc ON rc.constraint_schema = tc.constraint_schema AND rc.constraint_name = tc.constraint_name
+JOIN information_schema.key_column_usage ccu ON ccu.constraint_schema = rc.unique_constraint_schema AND ccu.constraint_name = rc.unique_constraint_name AND ccu.ordinal_position = kcu.position_in_unique_constraint
+WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = current_schema() AND tc.table_name = $1
+ORDER BY tc.constraint_name, kcu.ordinal_position`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	foreignKeys := make([]rem.ForeignKeySchema, 0)
+	for rows.Next() {
+		var column, name, onDelete, onUpdate, referencedColumn, referencedTable string
+		if err := rows.Scan(&name, &column, &referencedTable, &referencedColumn, &onUpdate, &onDelete); err != nil {
+			return nil, err
+		}
+		if len(foreignKeys) == 0 || foreignKeys[len(foreignKeys)-1].Name != name {
+			foreignKeys = append(foreignKeys, rem.ForeignKeySchema{
+				Name:            name,
+				OnDelete:        onDelete,
+				OnUpdate:        onUpdate,
+				ReferencedTable: referencedTable,
+			})
+		}
+		foreignKey := &foreignKeys[len(foreignKeys)-1]
+		foreignKey.Columns = append(foreignKey.Columns, column)
+		foreignKey.ReferencedColumns = append(foreignKey.ReferencedColumns, referencedColumn)
+	}
+	return foreignKeys, rows.Err()
+}
+
+func (dialect PqDialect) introspectIndexes(ctx context.Context, db rem.Executor, table string) ([]rem.Index, error) {
+	rows, err := db.QueryContext(ctx, `SELECT i.relname, ix.indisunique, am.amname, a.attname, COALESCE(pg_get_expr(ix.indpred, ix.indrelid), '')
+FROM pg_index ix
+JOIN pg_class t ON t.oid = ix.indrelid
+JOIN pg_class i ON i.oid = ix.indexrelid
+JOIN pg_am am ON am.oid = i.relam
+JOIN pg_namespace n ON n.oid = t.relnamespace
+JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord) ON true
+JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+WHERE n.nspname = current_schema() AND t.relname = $1 AND NOT ix.indisprimary
+ORDER BY i.relname, k.ord`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	indexes := make([]rem.Index, 0)
+	for rows.Next() {
+		var column, method, name, where string
+		var unique bool
+		if err := rows.Scan(&name, &unique, &method, &column, &where); err != nil {
+			return nil, err
+		}
+		if len(indexes) == 0 || indexes[len(indexes)-1].Name != name {
+			index := rem.Index{
+				Name:   name,
+				Unique: unique,
+				Where:  where,
+			}
+			if method != "btree" {
+				index.Method = strings.ToUpper(method)
+			}
+			indexes = append(indexes, index)
+		}
+		index := &indexes[len(indexes)-1]
+		index.Columns = append(index.Columns, column)
+	}
+	return indexes, rows.Err()
+}
+
+func (dialect PqDialect) introspectPrimaryKey(ctx context.Context, db rem.Executor, table string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT kcu.column_name
+FROM information_schema.table_constraints tc
+JOIN information_schema.key_column_usage kcu ON kcu.constraint_schema = tc.constraint_schema AND kcu.constraint_name = tc.constraint_name
+WHERE tc.constraint_type = 'PRIMARY KEY' AND tc.table_schema = current_schema() AND tc.table_name = $1
+ORDER BY kcu.ordinal_position`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	primaryKey := make([]string, 0)
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, err
+		}
+		primaryKey = append(primaryKey, column)
+	}
+	return primaryKey, rows.Err()
+}
+
+func (dialect PqDialect) IntrospectTable(ctx context.Context, db rem.Executor, table string) (*rem.TableSchema, error) {
+	columns, err := dialect.introspectColumns(ctx, db, table)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%w: %s", rem.ErrTableNotExist, table)
+	}
+	primaryKey, err := dialect.introspectPrimaryKey(ctx, db, table)
+	if err != nil {
+		return nil, err
+	}
+	foreignKeys, err := dialect.introspectForeignKeys(ctx, db, table)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := dialect.introspectIndexes(ctx, db, table)
+	if err != nil {
+		return nil, err
+	}
+	return &rem.TableSchema{
+		Columns:     columns,
+		ForeignKeys: foreignKeys,
+		Indexes:     indexes,
+		Name:        table,
+		PrimaryKey:  primaryKey,
+	}, nil
+}
+
+func (dialect PqDialect) IsRetryableError(err error) bool {
+	var pqErr interface{ SQLState() string }
+	if errors.As(err, &pqErr) {
+		switch pqErr.SQLState() {
+		case "40001", "40P01":
+			return true
+		}
+	}
+	return false
+}
+
+func (dialect PqDialect) LockMigrations(ctx context.Context, db *sql.DB, timeout time.Duration) (func() error, error) {
+	// Session-level advisory locks are held by a dedicated connection.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := dialect.acquireMigrationLock(ctx, conn, timeout); err != nil {
+		var pqErr interface{ SQLState() string }
+		if errors.As(err, &pqErr) && pqErr.SQLState() == "55P03" {
+			err = errors.Join(rem.ErrMigrationLockTimeout, err)
+		}
+		return nil, errors.Join(err, conn.Close())
+	}
+	return func() error {
+		_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey)
+		return errors.Join(err, conn.Close())
+	}, nil
+}
+
+func (dialect PqDialect) acquireMigrationLock(ctx context.Context, conn *sql.Conn, timeout time.Duration) error {
+	if timeout <= 0 {
+		_, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey)
+		return err
+	}
+
+	// SET LOCAL ends with the transaction, so the timeout never stays on the pooled connection. The advisory lock is held by the session.
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL lock_timeout = %d", max(timeout.Milliseconds(), 1))); err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+	return tx.Commit()
+}
+
+func (dialect PqDialect) parseColumnType(definition string) (string, bool) {
+	upper := strings.ToUpper(definition)
+	end := len(definition)
+	for _, keyword := range []string{" PRIMARY KEY", " NOT NULL", " NULL", " REFERENCES ", " DEFAULT ", " UNIQUE"} {
+		if i := strings.Index(upper, keyword); i >= 0 && i < end {
+			end = i
+		}
+	}
+	nullable := !strings.Contains(upper, " NOT NULL") && !strings.Contains(upper, " PRIMARY KEY")
+	return strings.TrimSpace(definition[:end]), nullable
+}
+
 func (dialect PqDialect) Param(identifier int) string {
 	var query strings.Builder
 	query.WriteString("$")
@@ -459,6 +1041,10 @@ func (dialect PqDialect) Param(identifier int) string {
 	return query.String()
 }
 
+func (dialect PqDialect) ParamLimit() int {
+	return 65535
+}
+
 func (dialect PqDialect) QuoteIdentifier(identifier string) string {
 	// 100-500ns all the way up to ~45us on early op for some reason.
 	var query strings.Builder
@@ -470,3 +1056,11 @@ func (dialect PqDialect) QuoteIdentifier(identifier string) string {
 	}
 	return query.String()
 }
+
+func (dialect PqDialect) SupportsReturning() bool {
+	return true
+}
+
+func (dialect PqDialect) SupportsTransactionalDDL() bool {
+	return true
+}
@@ -1,11 +1,16 @@
 package pqdialect
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
 	"sort"
 	"testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/evantbyrne/rem"
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
@@ -114,6 +119,61 @@ func TestBuildInsert(t *testing.T) {
 	if !slices.Equal(args, expectedArgs) {
 		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
 	}
+
+	// RETURNING
+	config.Returning = []string{"test_id"}
+	expectedSql = `INSERT INTO "testmodel" ("test_value_1","test_value_2") VALUES ($1,$2) RETURNING "test_id"`
+	queryString, args, err = dialect.BuildInsert(config, map[string]interface{}{
+		"test_value_1": "foo",
+		"test_value_2": "bar",
+	}, "test_value_1", "test_value_2")
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+	if !slices.Equal(args, expectedArgs) {
+		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
+	}
+}
+
+func TestBuildInsertMany(t *testing.T) {
+	type testModel struct {
+		Id     int64  `db:"test_id" db_primary:"true"`
+		Value1 string `db:"test_value_1" db_max_length:"100"`
+		Value2 string `db:"test_value_2" db_max_length:"100"`
+	}
+
+	dialect := PqDialect{}
+	model := rem.Use[testModel]()
+
+	config := model.Query().Config
+	config.Fields = model.Fields
+	config.Table = "testmodel"
+	expectedArgs := []interface{}{"foo", "bar", "baz", "qux"}
+	expectedSql := `INSERT INTO "testmodel" ("test_value_1","test_value_2") VALUES ($1,$2),($3,$4)`
+	queryString, args, err := dialect.BuildInsertMany(config, []map[string]interface{}{
+		{"test_value_1": "foo", "test_value_2": "bar"},
+		{"test_value_1": "baz", "test_value_2": "qux"},
+	}, "test_value_1", "test_value_2")
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+	if !slices.Equal(args, expectedArgs) {
+		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
+	}
+
+	_, _, err = dialect.BuildInsertMany(config, []map[string]interface{}{
+		{"test_value_1": "foo", "test_value_2": "bar"},
+		{"test_value_1": "baz"},
+	}, "test_value_1", "test_value_2")
+	if err == nil {
+		t.Error("Expected error for missing column")
+	}
 }
 
 func TestBuildSelect(t *testing.T) {
@@ -228,6 +288,28 @@ func TestBuildSelect(t *testing.T) {
 		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
 	}
 
+	// GROUP BY and HAVING
+	config = model.Select("group_id", rem.As(rem.Sum("amount"), "total")).
+		Filter("id", ">", 1).
+		GroupBy("group_id").
+		Having(rem.Q(rem.Sum("amount"), ">", 100), rem.Q(rem.Count("*"), ">=", 2)).
+		Sort("group_id").
+		Config
+	config.Fields = model.Fields
+	config.Table = "testmodel"
+	expectedArgs = []interface{}{1, 100, 2}
+	expectedSql = `SELECT "group_id",sum("amount") AS "total" FROM "testmodel" WHERE "id" > $1 GROUP BY "group_id" HAVING ( sum("amount") > $2 AND count(*) >= $3 ) ORDER BY "group_id" ASC`
+	queryString, args, err = dialect.BuildSelect(config)
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+	if !slices.Equal(args, expectedArgs) {
+		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
+	}
+
 	// LIMIT and OFFSET
 	config = model.Filter("id", "=", 1).Offset(20).Limit(10).Config
 	config.Fields = model.Fields
@@ -244,6 +326,54 @@ func TestBuildSelect(t *testing.T) {
 	if !slices.Equal(args, expectedArgs) {
 		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
 	}
+
+	// FOR UPDATE
+	config = model.Filter("id", "=", 1).Limit(10).ForUpdate().SkipLocked().Config
+	config.Fields = model.Fields
+	config.Table = "testmodel"
+	expectedArgs = []interface{}{1, 10}
+	expectedSql = `SELECT * FROM "testmodel" WHERE "id" = $1 LIMIT $2 FOR UPDATE SKIP LOCKED`
+	queryString, args, err = dialect.BuildSelect(config)
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+	if !slices.Equal(args, expectedArgs) {
+		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
+	}
+
+	// FOR SHARE
+	config = model.ForShare().NoWait().Config
+	config.Fields = model.Fields
+	config.Table = "testmodel"
+	expectedSql = `SELECT * FROM "testmodel" FOR SHARE NOWAIT`
+	queryString, _, err = dialect.BuildSelect(config)
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+
+	config = model.Query().SkipLocked().Config
+	config.Fields = model.Fields
+	config.Table = "testmodel"
+	_, _, err = dialect.BuildSelect(config)
+	if err == nil || err.Error() != "rem: NOWAIT and SKIP LOCKED require FOR SHARE or FOR UPDATE" {
+		t.Errorf("Expected lock option error, got '%v'", err)
+	}
+
+	// Count
+	config = model.Query().ForUpdate().Config
+	config.Count = true
+	config.Fields = model.Fields
+	config.Table = "testmodel"
+	_, _, err = dialect.BuildSelect(config)
+	if err == nil || err.Error() != "rem: cannot lock rows when counting. Lock them with a separate query" {
+		t.Errorf("Expected count lock error, got '%v'", err)
+	}
 }
 
 func TestBuildTableColumnAdd(t *testing.T) {
@@ -267,6 +397,39 @@ func TestBuildTableColumnAdd(t *testing.T) {
 	}
 }
 
+func TestBuildTableColumnAlter(t *testing.T) {
+	type testModel struct {
+		Id    int64          `db:"test_id" db_primary:"true"`
+		Name  sql.NullString `db:"test_name" db_max_length:"100" db_default:"'x'"`
+		Value string         `db:"test_value" db_max_length:"100"`
+	}
+
+	dialect := PqDialect{}
+	model := rem.Use[testModel]()
+	config := rem.QueryConfig{
+		Fields: model.Fields,
+		Table:  "testmodel",
+	}
+	for column, expectedSql := range map[string]string{
+		"test_id":    `ALTER TABLE "testmodel" ALTER COLUMN "test_id" TYPE BIGINT USING "test_id"::BIGINT, ALTER COLUMN "test_id" SET NOT NULL`,
+		"test_name":  `ALTER TABLE "testmodel" ALTER COLUMN "test_name" TYPE VARCHAR(100) USING "test_name"::VARCHAR(100), ALTER COLUMN "test_name" DROP NOT NULL, ALTER COLUMN "test_name" SET DEFAULT 'x'`,
+		"test_value": `ALTER TABLE "testmodel" ALTER COLUMN "test_value" TYPE VARCHAR(100) USING "test_value"::VARCHAR(100), ALTER COLUMN "test_value" SET NOT NULL, ALTER COLUMN "test_value" DROP DEFAULT`,
+	} {
+		queryStrings, err := dialect.BuildTableColumnAlter(config, column)
+		if err != nil {
+			t.Errorf("Unexpected error %s", err.Error())
+		}
+		if len(queryStrings) != 1 || queryStrings[0] != expectedSql {
+			t.Errorf("Expected '%s', got '%s'", expectedSql, queryStrings)
+		}
+	}
+
+	_, err := dialect.BuildTableColumnAlter(config, "missing")
+	if err == nil || err.Error() != "rem: invalid column 'missing' on model for table 'testmodel'" {
+		t.Errorf("Expected invalid column error, got '%v'", err)
+	}
+}
+
 func TestBuildTableColumnDrop(t *testing.T) {
 	dialect := PqDialect{}
 	config := rem.QueryConfig{Table: "testmodel"}
@@ -280,6 +443,19 @@ func TestBuildTableColumnDrop(t *testing.T) {
 	}
 }
 
+func TestBuildTableColumnRename(t *testing.T) {
+	dialect := PqDialect{}
+	config := rem.QueryConfig{Table: "testmodel"}
+	expectedSql := `ALTER TABLE "testmodel" RENAME COLUMN "test_value" TO "value"`
+	queryString, err := dialect.BuildTableColumnRename(config, "test_value", "value")
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+}
+
 func TestBuildTableCreate(t *testing.T) {
 	type testModel struct {
 		Id     int64  `db:"test_id" db_primary:"true"`
@@ -317,6 +493,57 @@ func TestBuildTableCreate(t *testing.T) {
 	}
 }
 
+func TestBuildTableCreateComposite(t *testing.T) {
+	type testPairs struct {
+		A int64  `db:"a" db_primary:"true"`
+		B string `db:"b" db_primary:"true" db_max_length:"50"`
+	}
+	type testEvents struct {
+		Id   int64                     `db:"id" db_primary:"true"`
+		Pair rem.ForeignKey[testPairs] `db:"pair_a,pair_b" db_on_delete:"CASCADE"`
+	}
+
+	dialect := PqDialect{}
+	pairs := rem.Use[testPairs]()
+	config := rem.QueryConfig{
+		Fields:         pairs.Fields,
+		PrimaryColumns: pairs.PrimaryColumns,
+		Table:          pairs.Table,
+	}
+	expectedSql := `CREATE TABLE "testpairs" (
+	"a" BIGINT NOT NULL,
+	"b" VARCHAR(50) NOT NULL,
+	PRIMARY KEY ("a", "b")
+)`
+	queryString, err := dialect.BuildTableCreate(config, rem.TableCreateConfig{})
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+
+	events := rem.Use[testEvents]()
+	config = rem.QueryConfig{
+		Fields:         events.Fields,
+		PrimaryColumns: events.PrimaryColumns,
+		Table:          events.Table,
+	}
+	expectedSql = `CREATE TABLE "testevents" (
+	"id" BIGSERIAL PRIMARY KEY NOT NULL,
+	"pair_a" BIGINT NOT NULL,
+	"pair_b" VARCHAR(50) NOT NULL,
+	FOREIGN KEY ("pair_a", "pair_b") REFERENCES "testpairs" ("a", "b") ON DELETE CASCADE
+)`
+	queryString, err = dialect.BuildTableCreate(config, rem.TableCreateConfig{})
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+}
+
 func TestBuildTableDrop(t *testing.T) {
 	dialect := PqDialect{}
 	config := rem.QueryConfig{Table: "testmodel"}
@@ -339,6 +566,100 @@ func TestBuildTableDrop(t *testing.T) {
 	}
 }
 
+func TestBuildTableIndexCreate(t *testing.T) {
+	type testModel struct {
+		Email string `db:"email" db_index:"testmodel_email_idx" db_index_unique:"true" db_index_where:"deleted_at IS NULL"`
+		Id    int64  `db:"id" db_primary:"true"`
+		Name  string `db:"name" db_index:"true,testmodel_pair_idx"`
+		Slug  string `db:"slug" db_index:"testmodel_pair_idx"`
+		Tags  string `db:"tags" db_index:"testmodel_tags_idx" db_index_method:"GIN"`
+	}
+
+	dialect := PqDialect{}
+	model := rem.Use[testModel]()
+	config := rem.QueryConfig{
+		Indexes: model.Indexes,
+		Table:   model.Table,
+	}
+
+	expectedSql := `CREATE INDEX "testmodel_name_idx" ON "testmodel" ("name")`
+	queryString, err := dialect.BuildTableIndexCreate(config, "testmodel_name_idx", rem.TableIndexCreateConfig{})
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+
+	expectedSql = `CREATE UNIQUE INDEX IF NOT EXISTS "testmodel_email_idx" ON "testmodel" ("email") WHERE deleted_at IS NULL`
+	queryString, err = dialect.BuildTableIndexCreate(config, "testmodel_email_idx", rem.TableIndexCreateConfig{IfNotExists: true})
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+
+	expectedSql = `CREATE INDEX "testmodel_tags_idx" ON "testmodel" USING GIN ("tags")`
+	queryString, err = dialect.BuildTableIndexCreate(config, "testmodel_tags_idx", rem.TableIndexCreateConfig{})
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+
+	expectedSql = `CREATE INDEX "testmodel_pair_idx" ON "testmodel" ("name", "slug")`
+	queryString, err = dialect.BuildTableIndexCreate(config, "testmodel_pair_idx", rem.TableIndexCreateConfig{})
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+
+	_, err = dialect.BuildTableIndexCreate(config, "bogus", rem.TableIndexCreateConfig{})
+	if err == nil || err.Error() != "rem: invalid index 'bogus' on model for table 'testmodel'" {
+		t.Errorf("Expected error, got '%v'", err)
+	}
+}
+
+func TestBuildTableIndexDrop(t *testing.T) {
+	dialect := PqDialect{}
+	config := rem.QueryConfig{Table: "testmodel"}
+
+	expectedSql := `DROP INDEX "testmodel_name_idx"`
+	queryString, err := dialect.BuildTableIndexDrop(config, "testmodel_name_idx", rem.TableIndexDropConfig{})
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+
+	expectedSql = `DROP INDEX IF EXISTS "testmodel_name_idx"`
+	queryString, err = dialect.BuildTableIndexDrop(config, "testmodel_name_idx", rem.TableIndexDropConfig{IfExists: true})
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+}
+
+func TestBuildTableRename(t *testing.T) {
+	dialect := PqDialect{}
+	config := rem.QueryConfig{Table: "testmodel"}
+	expectedSql := `ALTER TABLE "testmodel" RENAME TO "renamed"`
+	queryString, err := dialect.BuildTableRename(config, "renamed")
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+}
+
 func TestBuildUpdate(t *testing.T) {
 	type testModel struct {
 		Id     int64  `db:"test_id" db_primary:"true"`
@@ -368,6 +689,87 @@ func TestBuildUpdate(t *testing.T) {
 	if !slices.Equal(args, expectedArgs) {
 		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
 	}
+
+	// RETURNING
+	config = rem.Use[testModel]().Filter("test_id", "=", 1).Config
+	config.Fields = model.Fields
+	config.Table = "testmodel"
+	config.Returning = []string{"test_id"}
+	expectedArgs = []interface{}{"foo", "bar", 1}
+	expectedSql = `UPDATE "testmodel" SET "test_value_1" = $1,"test_value_2" = $2 WHERE "test_id" = $3 RETURNING "test_id"`
+	queryString, args, err = dialect.BuildUpdate(config, map[string]interface{}{
+		"id":           123,
+		"test_value_1": "foo",
+		"test_value_2": "bar",
+	}, "test_value_1", "test_value_2")
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+	if !slices.Equal(args, expectedArgs) {
+		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
+	}
+}
+
+func TestBuildUpsert(t *testing.T) {
+	type testModel struct {
+		Id     int64  `db:"test_id" db_primary:"true"`
+		Value1 string `db:"test_value_1" db_max_length:"100" db_unique:"true"`
+		Value2 string `db:"test_value_2" db_max_length:"100"`
+	}
+
+	dialect := PqDialect{}
+	model := rem.Use[testModel]()
+	rowMap := map[string]interface{}{
+		"test_value_1": "foo",
+		"test_value_2": "bar",
+	}
+
+	config := model.Query().Config
+	config.Fields = model.Fields
+	config.Table = "testmodel"
+	config.Returning = []string{"test_id"}
+	expectedArgs := []interface{}{"foo", "bar"}
+	expectedSql := `INSERT INTO "testmodel" ("test_value_1","test_value_2") VALUES ($1,$2) ON CONFLICT ("test_value_1") DO UPDATE SET "test_value_2" = EXCLUDED."test_value_2" RETURNING "test_id"`
+	queryString, args, err := dialect.BuildUpsert(config, rowMap, rem.UpsertConfig{
+		ConflictColumns: []string{"test_value_1"},
+		UpdateColumns:   []string{"test_value_2"},
+	}, "test_value_1", "test_value_2")
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+	if !slices.Equal(args, expectedArgs) {
+		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
+	}
+
+	// DO NOTHING
+	config.Returning = nil
+	expectedSql = `INSERT INTO "testmodel" ("test_value_1","test_value_2") VALUES ($1,$2) ON CONFLICT ("test_value_1") DO NOTHING`
+	queryString, args, err = dialect.BuildUpsert(config, rowMap, rem.UpsertConfig{
+		ConflictColumns: []string{"test_value_1"},
+		DoNothing:       true,
+	}, "test_value_1", "test_value_2")
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+	if !slices.Equal(args, expectedArgs) {
+		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
+	}
+
+	_, _, err = dialect.BuildUpsert(config, rowMap, rem.UpsertConfig{
+		ConflictColumns: []string{"test_value_1"},
+	}, "test_value_1", "test_value_2")
+	if err == nil {
+		t.Error("Expected error for missing update columns")
+	}
 }
 
 func TestColumnType(t *testing.T) {
@@ -448,4 +850,147 @@ func TestColumnType(t *testing.T) {
 			t.Fatalf(`dialect.ColumnType() returned '%s', but expected '%s' for '%#v'`, columnType, expected[fieldName], field)
 		}
 	}
+
+	// Composite primary keys are declared as a table constraint.
+	columnType, err := dialect.CompositePrimaryColumnType(model.Fields["test_id"])
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if columnType != "BIGINT NOT NULL" {
+		t.Errorf("Expected 'BIGINT NOT NULL', got '%s'", columnType)
+	}
+}
+
+type testPqError struct {
+	Code string
+}
+
+func (err *testPqError) Error() string {
+	return "pq: " + err.Code
+}
+
+func (err *testPqError) SQLState() string {
+	return err.Code
+}
+
+func TestIntrospectTable(t *testing.T) {
+	dialect := PqDialect{}
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM information_schema.columns").
+		WithArgs("accounts").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name", "data_type", "character_maximum_length", "is_nullable", "column_default", "is_identity"}).
+			AddRow("id", "bigint", nil, "NO", "nextval('accounts_id_seq'::regclass)", "NO").
+			AddRow("name", "character varying", 100, "NO", "'foo'::character varying", "NO").
+			AddRow("group_a", "bigint", nil, "YES", nil, "NO").
+			AddRow("group_b", "text", nil, "YES", nil, "NO"))
+	mock.ExpectQuery("PRIMARY KEY").
+		WithArgs("accounts").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name"}).
+			AddRow("id"))
+	mock.ExpectQuery("FOREIGN KEY").
+		WithArgs("accounts").
+		WillReturnRows(sqlmock.NewRows([]string{"constraint_name", "column_name", "table_name", "column_name", "update_rule", "delete_rule"}).
+			AddRow("accounts_group_fkey", "group_a", "groups", "a", "NO ACTION", "CASCADE").
+			AddRow("accounts_group_fkey", "group_b", "groups", "b", "NO ACTION", "CASCADE"))
+	mock.ExpectQuery("FROM pg_index").
+		WithArgs("accounts").
+		WillReturnRows(sqlmock.NewRows([]string{"relname", "indisunique", "amname", "attname", "indpred"}).
+			AddRow("accounts_group_idx", false, "btree", "group_a", "").
+			AddRow("accounts_group_idx", false, "btree", "group_b", "").
+			AddRow("accounts_name_idx", true, "gin", "name", "(group_a IS NULL)"))
+	schema, err := dialect.IntrospectTable(context.Background(), db, "accounts")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	expected := &rem.TableSchema{
+		Columns: []rem.ColumnSchema{
+			{AutoIncrement: true, Name: "id", Type: "BIGINT"},
+			{Default: sql.NullString{String: "'foo'::character varying", Valid: true}, Name: "name", Type: "VARCHAR(100)"},
+			{Name: "group_a", Nullable: true, Type: "BIGINT"},
+			{Name: "group_b", Nullable: true, Type: "TEXT"},
+		},
+		ForeignKeys: []rem.ForeignKeySchema{
+			{Columns: []string{"group_a", "group_b"}, Name: "accounts_group_fkey", OnDelete: "CASCADE", OnUpdate: "NO ACTION", ReferencedColumns: []string{"a", "b"}, ReferencedTable: "groups"},
+		},
+		Indexes: []rem.Index{
+			{Columns: []string{"group_a", "group_b"}, Name: "accounts_group_idx"},
+			{Columns: []string{"name"}, Method: "GIN", Name: "accounts_name_idx", Unique: true, Where: "(group_a IS NULL)"},
+		},
+		Name:       "accounts",
+		PrimaryKey: []string{"id"},
+	}
+	if !reflect.DeepEqual(schema, expected) {
+		t.Errorf("Expected '%+v', got '%+v'", expected, schema)
+	}
+
+	mock.ExpectQuery("information_schema.columns").
+		WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}))
+	if _, err := dialect.IntrospectTable(context.Background(), db, "missing"); !errors.Is(err, rem.ErrTableNotExist) {
+		t.Errorf("Expected missing table error, got '%v'", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	dialect := PqDialect{}
+	expected := map[error]bool{
+		&testPqError{Code: "40001"}:                            true,
+		&testPqError{Code: "40P01"}:                            true,
+		fmt.Errorf("wrapped: %w", &testPqError{Code: "40001"}): true,
+		&testPqError{Code: "23505"}:                            false,
+		errors.New("40001"):                                    false,
+	}
+	for err, retryable := range expected {
+		if dialect.IsRetryableError(err) != retryable {
+			t.Errorf("Expected '%t' for '%v'", retryable, err)
+		}
+	}
+}
+
+func TestLockMigrations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SET LOCAL lock_timeout = 1500").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SELECT pg_advisory_lock\\(\\$1\\)").WithArgs(migrationLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectExec("SELECT pg_advisory_unlock\\(\\$1\\)").WithArgs(migrationLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	unlock, err := PqDialect{}.LockMigrations(context.Background(), db, 1500*time.Millisecond)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err := unlock(); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SET LOCAL lock_timeout = 10").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SELECT pg_advisory_lock\\(\\$1\\)").WithArgs(migrationLockKey).WillReturnError(&testPqError{Code: "55P03"})
+	mock.ExpectRollback()
+	_, err = PqDialect{}.LockMigrations(context.Background(), db, 10*time.Millisecond)
+	if !errors.Is(err, rem.ErrMigrationLockTimeout) {
+		t.Errorf("Expected rem.ErrMigrationLockTimeout, got '%v'", err)
+	}
+
+	// No timeout waits indefinitely.
+	mock.ExpectExec("SELECT pg_advisory_lock\\(\\$1\\)").WithArgs(migrationLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	if _, err := (PqDialect{}).LockMigrations(context.Background(), db, 0); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
 }
@@ -3,11 +3,14 @@ package rem
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
 	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 )
 
 type JoinClause struct {
@@ -17,19 +20,30 @@ type JoinClause struct {
 }
 
 type QueryConfig struct {
-	Count        bool
-	Context      context.Context
-	FetchRelated []string
-	Fields       map[string]reflect.StructField
-	Filters      []FilterClause
-	Joins        []JoinClause
-	Limit        interface{}
-	Offset       interface{}
-	Params       []interface{}
-	Selected     []interface{}
-	Sort         []string
-	Table        string
-	Transaction  *sql.Tx
+	ChunkSize           int
+	Count               bool
+	Context             context.Context
+	FetchRelated        []string
+	FetchRelatedQueries map[string]interface{}
+	Fields              map[string]reflect.StructField
+	Filters             []FilterClause
+	ForShare            bool
+	ForUpdate           bool
+	GroupBy             []interface{}
+	Having              []FilterClause
+	Indexes             []Index
+	Joins               []JoinClause
+	Limit               interface{}
+	NoWait              bool
+	Offset              interface{}
+	Params              []interface{}
+	PrimaryColumns      []string
+	Returning           []string
+	Selected            []interface{}
+	SkipLocked          bool
+	Sort                []string
+	Table               string
+	Transaction         *sql.Tx
 }
 
 type Query[T any] struct {
@@ -41,9 +55,11 @@ type Query[T any] struct {
 	dialect Dialect
 }
 
-func (query *Query[T]) All(db *sql.DB) ([]*T, error) {
+func (query *Query[T]) All(db Executor) ([]*T, error) {
 	query.detectDialect()
-	query.configure()
+	if err := query.configure(); err != nil {
+		return make([]*T, 0), err
+	}
 
 	queryString, args, err := query.dialect.BuildSelect(query.Config)
 	if err != nil {
@@ -58,9 +74,11 @@ func (query *Query[T]) All(db *sql.DB) ([]*T, error) {
 	return query.slice(db)
 }
 
-func (query *Query[T]) AllToMap(db *sql.DB) ([]map[string]interface{}, error) {
+func (query *Query[T]) AllToMap(db Executor) ([]map[string]interface{}, error) {
 	query.detectDialect()
-	query.configure()
+	if err := query.configure(); err != nil {
+		return nil, err
+	}
 
 	queryString, args, err := query.dialect.BuildSelect(query.Config)
 	if err != nil {
@@ -94,9 +112,39 @@ func (query *Query[T]) AllToMap(db *sql.DB) ([]map[string]interface{}, error) {
 	return mapped, nil
 }
 
-func (query *Query[T]) configure() {
+func (query *Query[T]) ChunkSize(size int) *Query[T] {
+	query.Config.ChunkSize = size
+	return query
+}
+
+func (query *Query[T]) columnType(field reflect.StructField) (string, error) {
+	// Composite primary keys are declared as a table constraint.
+	if typer, ok := query.dialect.(CompositePrimaryColumnTyper); ok && len(query.Config.PrimaryColumns) > 1 && field.Tag.Get("db_primary") == "true" {
+		return typer.CompositePrimaryColumnType(field)
+	}
+	return query.dialect.ColumnType(field)
+}
+
+func (query *Query[T]) configure() error {
+	if query.Model.Error != nil {
+		return query.Model.Error
+	}
 	query.Config.Fields = query.Model.Fields
+	query.Config.Indexes = query.Model.Indexes
+	query.Config.PrimaryColumns = query.Model.PrimaryColumns
 	query.Config.Table = query.Model.Table
+	return nil
+}
+
+func (query *Query[T]) CheckSchema(db Executor) (*TableDiff, error) {
+	schema, err := query.TableIntrospect(db)
+	if errors.Is(err, ErrTableNotExist) {
+		return query.SchemaDiff(nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return query.SchemaDiff(schema)
 }
 
 func (query *Query[T]) Context(context context.Context) *Query[T] {
@@ -104,9 +152,11 @@ func (query *Query[T]) Context(context context.Context) *Query[T] {
 	return query
 }
 
-func (query *Query[T]) Count(db *sql.DB) (uint, error) {
+func (query *Query[T]) Count(db Executor) (uint, error) {
 	query.detectDialect()
-	query.configure()
+	if err := query.configure(); err != nil {
+		return 0, err
+	}
 
 	var count uint
 
@@ -117,17 +167,7 @@ func (query *Query[T]) Count(db *sql.DB) (uint, error) {
 		return count, err
 	}
 
-	if query.Config.Transaction != nil {
-		if query.Config.Context != nil {
-			err = query.Config.Transaction.QueryRowContext(query.Config.Context, queryString, args...).Scan(&count)
-		} else {
-			err = query.Config.Transaction.QueryRow(queryString, args...).Scan(&count)
-		}
-	} else if query.Config.Context != nil {
-		err = db.QueryRowContext(query.Config.Context, queryString, args...).Scan(&count)
-	} else {
-		err = db.QueryRow(queryString, args...).Scan(&count)
-	}
+	err = query.executor(db).QueryRowContext(query.queryContext(), queryString, args...).Scan(&count)
 	if err != nil {
 		return count, err
 	}
@@ -135,37 +175,53 @@ func (query *Query[T]) Count(db *sql.DB) (uint, error) {
 	return count, nil
 }
 
-func (query *Query[T]) dbExec(db *sql.DB, queryString string, args ...interface{}) (sql.Result, error) {
-	if query.Config.Transaction != nil {
-		if query.Config.Context != nil {
-			return query.Config.Transaction.ExecContext(query.Config.Context, queryString, args...)
-		}
-		return query.Config.Transaction.Exec(queryString, args...)
-	}
+func (query *Query[T]) dbExec(db Executor, queryString string, args ...interface{}) (sql.Result, error) {
+	return query.executor(db).ExecContext(query.queryContext(), queryString, args...)
+}
 
-	if query.Config.Context != nil {
-		return db.ExecContext(query.Config.Context, queryString, args...)
-	}
-	return db.Exec(queryString, args...)
+func (query *Query[T]) dbQuery(db Executor, queryString string, args ...interface{}) (*sql.Rows, error) {
+	return query.executor(db).QueryContext(query.queryContext(), queryString, args...)
 }
 
-func (query *Query[T]) dbQuery(db *sql.DB, queryString string, args ...interface{}) (*sql.Rows, error) {
-	if query.Config.Transaction != nil {
-		if query.Config.Context != nil {
-			return query.Config.Transaction.QueryContext(query.Config.Context, queryString, args...)
+func (query *Query[T]) dbReturning(db Executor, row *T, queryString string, args ...interface{}) (sql.Result, error) {
+	rows, err := query.dbQuery(db, queryString, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := &returningResult{}
+	var data map[string]interface{}
+	for rows.Next() {
+		result.rowsAffected++
+		if result.rowsAffected > 1 {
+			continue
 		}
-		return query.Config.Transaction.Query(queryString, args...)
+		if data, err = query.Model.ScanToMap(rows); err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	if query.Config.Context != nil {
-		return db.QueryContext(query.Config.Context, queryString, args...)
+	// Rows are only written back when the statement affected exactly the one row.
+	if result.rowsAffected == 1 {
+		if err := query.Model.scanMapInto(reflect.ValueOf(row).Elem(), data); err != nil {
+			return nil, err
+		}
+		if pk, ok := data[query.Model.PrimaryColumn]; ok {
+			result.lastInsertId = pk
+		}
 	}
-	return db.Query(queryString, args...)
+	return result, nil
 }
 
-func (query *Query[T]) Delete(db *sql.DB) (sql.Result, error) {
+func (query *Query[T]) Delete(db Executor) (sql.Result, error) {
 	query.detectDialect()
-	query.configure()
+	if err := query.configure(); err != nil {
+		return nil, err
+	}
 
 	queryString, args, err := query.dialect.BuildDelete(query.Config)
 	if err != nil {
@@ -189,9 +245,18 @@ func (query *Query[T]) Dialect(dialect Dialect) *Query[T] {
 	return query
 }
 
-func (query *Query[T]) Exists(db *sql.DB) (bool, error) {
+func (query *Query[T]) executor(db Executor) Executor {
+	if query.Config.Transaction != nil {
+		return query.Config.Transaction
+	}
+	return db
+}
+
+func (query *Query[T]) Exists(db Executor) (bool, error) {
 	query.detectDialect()
-	query.configure()
+	if err := query.configure(); err != nil {
+		return false, err
+	}
 
 	query.Config.Limit = 1
 
@@ -204,7 +269,188 @@ func (query *Query[T]) Exists(db *sql.DB) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	return rows.Next(), nil
+	defer rows.Close()
+	if rows.Next() {
+		return true, nil
+	}
+	return false, rows.Err()
+}
+
+func (query *Query[T]) fetchRelated(db Executor, rows []*T) error {
+	paths := query.fetchRelatedPaths()
+	if len(paths) == 0 {
+		return nil
+	}
+
+	columns := make([]string, 0)
+	nested := make(map[string][]string)
+	for _, path := range paths {
+		column, rest, _ := strings.Cut(path, ".")
+		if _, ok := nested[column]; !ok {
+			columns = append(columns, column)
+			nested[column] = make([]string, 0)
+		}
+		if rest != "" {
+			nested[column] = append(nested[column], rest)
+		}
+	}
+
+	meta := query.Model.metadata()
+	fields := make([]*modelField, len(columns))
+	for i, column := range columns {
+		field, ok := meta.byName[column]
+		if !ok {
+			if len(rows) == 0 {
+				continue
+			}
+			return fmt.Errorf("rem: invalid field '%s' for fetching related. Field does not exist on model", column)
+		}
+		switch field.kind {
+		case fieldForeignKey, fieldNullForeignKey, fieldManyToMany:
+		case fieldOneToMany:
+			if field.relatedForeignField == "" && len(rows) > 0 {
+				return fmt.Errorf("rem: invalid db tag of '%s' for fetching related on field '%s'. No fields with a matching column exist on the related model", field.column, column)
+			}
+		default:
+			if len(rows) > 0 {
+				return fmt.Errorf("rem: invalid field '%s' for fetching related. Field must be of type rem.ForeignKey[To], rem.NullForeignKey[To], rem.OneToMany[To], or rem.ManyToMany[To]", column)
+			}
+		}
+		fields[i] = field
+	}
+
+	relatedPks := make(map[string]relatedPk)
+	for _, row := range rows {
+		value := reflect.ValueOf(row).Elem()
+		for i, column := range columns {
+			field := fields[i]
+			valueFk := value.FieldByIndex(field.index)
+			rpk, ok := relatedPks[column]
+			if !ok {
+				rpk = relatedPk{RelatedValues: make([]interface{}, 0)}
+			}
+			switch field.kind {
+			case fieldForeignKey, fieldNullForeignKey:
+				if !valueFk.Field(1).Bool() {
+					continue
+				}
+				rpk.RelatedColumn = field.relatedPrimaryColumn
+				rpk.RelatedField = field.relatedPrimaryField
+				if field.isCompositeForeignKey() {
+					rpk.RelatedColumns = field.relatedKeyColumns
+				}
+				rpk.RelatedValues = append(rpk.RelatedValues, field.relatedKey(valueFk.Field(0).Elem()))
+			case fieldOneToMany:
+				rpk.RelatedColumn = field.column
+				rpk.RelatedField = field.relatedForeignField
+				rpk.RelatedValues = append(rpk.RelatedValues, value.FieldByName(query.Model.PrimaryField).Interface())
+			case fieldManyToMany:
+				rpk.RelatedColumn = field.column
+				rpk.RelatedValues = append(rpk.RelatedValues, value.FieldByName(query.Model.PrimaryField).Interface())
+			}
+			relatedPks[column] = rpk
+		}
+	}
+
+	if len(relatedPks) > 0 {
+		for i, column := range columns {
+			if rpk := relatedPks[column]; len(rpk.RelatedValues) > 0 {
+				field := fields[i]
+				fk := reflect.New(field.structField.Type)
+
+				if _, ok := fk.Interface().(manyToManyField); ok {
+					if err := query.fetchManyToMany(db, column, nested[column], rows, rpk); err != nil {
+						return err
+					}
+					continue
+				}
+
+				q := fk.MethodByName("Query").Call(nil)
+				if fn, ok := query.Config.FetchRelatedQueries[column]; ok {
+					fnValue := reflect.ValueOf(fn)
+					if fnValue.Kind() != reflect.Func ||
+						fnValue.Type().NumIn() != 1 ||
+						fnValue.Type().NumOut() != 1 ||
+						fnValue.Type().In(0) != q[0].Type() ||
+						fnValue.Type().Out(0) != q[0].Type() {
+						return fmt.Errorf("rem: invalid query function for fetching related on field '%s'. Expected func(%s) %s", column, q[0].Type(), q[0].Type())
+					}
+					q = fnValue.Call(q)
+					config := q[0].Elem().FieldByName("Config")
+					if !config.FieldByName("Limit").IsNil() || !config.FieldByName("Offset").IsNil() {
+						return fmt.Errorf("rem: Limit and Offset can't be used in the query function for fetching related on field '%s', because they would apply to all parent rows together", column)
+					}
+				}
+				if len(rpk.RelatedColumns) > 0 {
+					clauses := make([]interface{}, len(rpk.RelatedValues))
+					for j, tuple := range rpk.RelatedValues {
+						tupleClauses := make([]interface{}, len(rpk.RelatedColumns))
+						for k, relatedColumn := range rpk.RelatedColumns {
+							tupleClauses[k] = Q(relatedColumn, "=", tuple.([]interface{})[k])
+						}
+						clauses[j] = And(tupleClauses...)
+					}
+					q = q[0].MethodByName("FilterOr").CallSlice([]reflect.Value{
+						reflect.ValueOf(clauses),
+					})
+				} else {
+					q = q[0].MethodByName("Filter").Call([]reflect.Value{
+						reflect.ValueOf(rpk.RelatedColumn),
+						reflect.ValueOf("IN"),
+						reflect.ValueOf(rpk.RelatedValues),
+					})
+				}
+				if query.Config.Context != nil {
+					q = q[0].MethodByName("Context").Call([]reflect.Value{
+						reflect.ValueOf(query.Config.Context),
+					})
+				}
+				if len(nested[column]) > 0 {
+					q = q[0].MethodByName("FetchRelated").CallSlice([]reflect.Value{
+						reflect.ValueOf(nested[column]),
+					})
+				}
+				for path, fn := range query.Config.FetchRelatedQueries {
+					if rest, ok := strings.CutPrefix(path, column+"."); ok {
+						q = q[0].MethodByName("FetchRelatedQuery").Call([]reflect.Value{
+							reflect.ValueOf(rest),
+							reflect.ValueOf(fn),
+						})
+					}
+				}
+				q = q[0].MethodByName("All").Call([]reflect.Value{
+					reflect.ValueOf(query.executor(db)),
+				})
+				if err, ok := q[1].Interface().(error); ok && err != nil {
+					return err
+				}
+				for _, row := range rows {
+					value := reflect.ValueOf(row).Elem()
+					valueFk := value.FieldByIndex(field.index)
+
+					if field.kind == fieldOneToMany {
+						for j := 0; j < q[0].Len(); j++ {
+							fkRow := q[0].Index(j).Elem()
+							relatedFieldId := fkRow.FieldByName(rpk.RelatedField).Field(0).Elem().FieldByName(query.Model.PrimaryField).Interface()
+							if value.FieldByName(query.Model.PrimaryField).Interface() == relatedFieldId {
+								valueFk.FieldByName("Rows").Set(reflect.Append(valueFk.FieldByName("Rows"), fkRow.Addr()))
+							}
+						}
+					} else if valueFk.Field(1).Bool() {
+						for j := 0; j < q[0].Len(); j++ {
+							fkRow := q[0].Index(j)
+							if field.relatedKeyEqual(valueFk.Field(0).Elem(), fkRow.Elem()) {
+								valueFk.Field(0).Set(fkRow)
+								break
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return nil
 }
 
 func (query *Query[T]) FetchRelated(columns ...string) *Query[T] {
@@ -212,6 +458,58 @@ func (query *Query[T]) FetchRelated(columns ...string) *Query[T] {
 	return query
 }
 
+func (query *Query[T]) fetchManyToMany(db Executor, column string, nested []string, rows []*T, rpk relatedPk) error {
+	manyToMany := newManyToManyField(query.Model.Fields[column])
+
+	config := QueryConfig{
+		Context:             query.Config.Context,
+		FetchRelated:        nested,
+		FetchRelatedQueries: make(map[string]interface{}),
+	}
+	for path, fn := range query.Config.FetchRelatedQueries {
+		if rest, ok := strings.CutPrefix(path, column+"."); ok {
+			config.FetchRelatedQueries[rest] = fn
+		}
+	}
+
+	primaryField, _ := query.Model.Type.FieldByName(query.Model.PrimaryField)
+	related, err := manyToMany.fetchManyToMany(query.executor(db), query.dialect, config, column, query.Config.FetchRelatedQueries[column], primaryField.Type, rpk.RelatedValues)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		value := reflect.ValueOf(row).Elem()
+		relatedRows := value.FieldByName(column).FieldByName("Rows")
+		if rowsValue, ok := related[value.FieldByName(query.Model.PrimaryField).Interface()]; ok {
+			relatedRows.Set(rowsValue)
+		} else {
+			relatedRows.Set(reflect.MakeSlice(relatedRows.Type(), 0, 0))
+		}
+	}
+	return nil
+}
+
+func (query *Query[T]) fetchRelatedPaths() []string {
+	paths := append([]string{}, query.Config.FetchRelated...)
+	queryPaths := maps.Keys(query.Config.FetchRelatedQueries)
+	sort.Strings(queryPaths)
+	for _, path := range queryPaths {
+		if !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+func (query *Query[T]) FetchRelatedQuery(path string, fn interface{}) *Query[T] {
+	if query.Config.FetchRelatedQueries == nil {
+		query.Config.FetchRelatedQueries = make(map[string]interface{})
+	}
+	query.Config.FetchRelatedQueries[path] = fn
+	return query
+}
+
 func (query *Query[T]) Filter(column interface{}, operator string, value interface{}) *Query[T] {
 	if len(query.Config.Filters) > 0 {
 		query.Config.Filters = append(query.Config.Filters, FilterClause{Rule: "AND"})
@@ -274,9 +572,11 @@ func (query *Query[T]) FilterOr(clauses ...interface{}) *Query[T] {
 	return query
 }
 
-func (query *Query[T]) First(db *sql.DB) (*T, error) {
+func (query *Query[T]) First(db Executor) (*T, error) {
 	query.detectDialect()
-	query.configure()
+	if err := query.configure(); err != nil {
+		return nil, err
+	}
 
 	query.Limit(1)
 
@@ -307,9 +607,11 @@ func (query *Query[T]) First(db *sql.DB) (*T, error) {
 	return nil, sql.ErrNoRows
 }
 
-func (query *Query[T]) FirstToMap(db *sql.DB) (map[string]interface{}, error) {
+func (query *Query[T]) FirstToMap(db Executor) (map[string]interface{}, error) {
 	query.detectDialect()
-	query.configure()
+	if err := query.configure(); err != nil {
+		return nil, err
+	}
 
 	query.Limit(1)
 
@@ -340,23 +642,157 @@ func (query *Query[T]) FirstToMap(db *sql.DB) (map[string]interface{}, error) {
 	return nil, sql.ErrNoRows
 }
 
-func (query *Query[T]) Insert(db *sql.DB, row *T) (sql.Result, error) {
+func (query *Query[T]) ForShare() *Query[T] {
+	query.Config.ForShare = true
+	query.Config.ForUpdate = false
+	return query
+}
+
+func (query *Query[T]) ForUpdate() *Query[T] {
+	query.Config.ForShare = false
+	query.Config.ForUpdate = true
+	return query
+}
+
+func (query *Query[T]) GroupBy(columns ...interface{}) *Query[T] {
+	query.Config.GroupBy = columns
+	return query
+}
+
+func (query *Query[T]) Having(clauses ...interface{}) *Query[T] {
+	if len(query.Config.Having) > 0 {
+		query.Config.Having = append(query.Config.Having, FilterClause{Rule: "AND"})
+	}
+	query.Config.Having = append(query.Config.Having, And(clauses...)...)
+	return query
+}
+
+func (query *Query[T]) Insert(db Executor, row *T) (sql.Result, error) {
 	query.detectDialect()
-	query.configure()
+	if err := query.configure(); err != nil {
+		return nil, err
+	}
 	rowMap, err := query.Model.ToMap(row)
 	if err != nil {
 		return nil, err
 	}
+	if supportsReturning(query.dialect) {
+		query.Config.Returning = query.Model.returningColumns()
+	}
 	queryString, args, err := query.dialect.BuildInsert(query.Config, rowMap, maps.Keys(rowMap)...)
 	if err != nil {
 		return nil, err
 	}
-	return query.dbExec(db, queryString, args...)
+	return query.insertRow(db, row, rowMap, queryString, args...)
+}
+
+func (query *Query[T]) insertRow(db Executor, row *T, rowMap map[string]interface{}, queryString string, args ...interface{}) (sql.Result, error) {
+	if len(query.Config.Returning) > 0 {
+		return query.dbReturning(db, row, queryString, args...)
+	}
+
+	result, err := query.dbExec(db, queryString, args...)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := rowMap[query.Model.PrimaryColumn]; !ok && len(query.Model.PrimaryFields) == 1 {
+		// Fallback for dialects without RETURNING. Drivers that don't support LastInsertId leave the primary key as-is.
+		field := reflect.ValueOf(row).Elem().FieldByName(query.Model.PrimaryField)
+		if id, err := result.LastInsertId(); err == nil && id != 0 {
+			switch field.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				field.SetInt(id)
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				field.SetUint(uint64(id))
+			}
+		}
+		query.Model.setRelations(reflect.ValueOf(row).Elem())
+	}
+	return result, nil
+}
+
+func (query *Query[T]) InsertMany(db Executor, rows []*T) (sql.Result, error) {
+	query.detectDialect()
+	if err := query.configure(); err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("rem: no rows specified for insert")
+	}
+
+	rowMaps := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		rowMap, err := query.Model.ToMap(row)
+		if err != nil {
+			return nil, err
+		}
+		rowMaps[i] = rowMap
+	}
+
+	columns := maps.Keys(rowMaps[0])
+	sort.Strings(columns)
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("rem: no columns specified for insert")
+	}
+	for i, rowMap := range rowMaps {
+		if len(rowMap) != len(columns) {
+			return nil, fmt.Errorf("rem: row %d has different columns than row 0 for insert. Primary keys must be either set or zero-valued on all rows", i)
+		}
+	}
+
+	paramLimit := defaultParamLimit
+	if limiter, ok := query.dialect.(ParamLimiter); ok {
+		paramLimit = limiter.ParamLimit()
+	}
+	batchSize := paramLimit / len(columns)
+	if batchSize < 1 {
+		return nil, fmt.Errorf("rem: %d columns exceed the dialect limit of %d parameters", len(columns), paramLimit)
+	}
+
+	insertBatches := func(db Executor) (*batchResult, error) {
+		result := &batchResult{}
+		for start := 0; start < len(rowMaps); start += batchSize {
+			end := start + batchSize
+			if end > len(rowMaps) {
+				end = len(rowMaps)
+			}
+			queryString, args, err := query.dialect.BuildInsertMany(query.Config, rowMaps[start:end], columns...)
+			if err != nil {
+				return result, err
+			}
+			batch, err := query.dbExec(db, queryString, args...)
+			if err != nil {
+				return result, err
+			}
+			result.results = append(result.results, batch)
+		}
+		return result, nil
+	}
+
+	// Multiple batches run in a transaction so that a failed batch doesn't leave earlier batches inserted.
+	if _, isTx := query.executor(db).(*sql.Tx); !isTx && len(rowMaps) > batchSize {
+		if _, ok := db.(TransactionBeginner); ok {
+			var result *batchResult
+			err := InTransaction(query.queryContext(), db, &TransactionConfig{Dialect: query.dialect}, func(tx *sql.Tx) error {
+				var err error
+				result, err = insertBatches(tx)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			return result, nil
+		}
+	}
+	return insertBatches(db)
 }
 
-func (query *Query[T]) InsertMap(db *sql.DB, data map[string]interface{}) (sql.Result, error) {
+func (query *Query[T]) InsertMap(db Executor, data map[string]interface{}) (sql.Result, error) {
 	query.detectDialect()
-	query.configure()
+	if err := query.configure(); err != nil {
+		return nil, err
+	}
 	queryString, args, err := query.dialect.BuildInsert(query.Config, data, maps.Keys(data)...)
 	if err != nil {
 		return nil, err
@@ -364,6 +800,36 @@ func (query *Query[T]) InsertMap(db *sql.DB, data map[string]interface{}) (sql.R
 	return query.dbExec(db, queryString, args...)
 }
 
+func (query *Query[T]) Iter(db Executor) (*Cursor[T], error) {
+	query.detectDialect()
+	if err := query.configure(); err != nil {
+		return nil, err
+	}
+
+	if query.Error != nil {
+		return nil, query.Error
+	}
+	if len(query.fetchRelatedPaths()) > 0 {
+		// Prefetching runs while the result set is open, which drivers such as pq and MySQL don't allow on the same connection.
+		switch query.executor(db).(type) {
+		case *sql.Conn, *sql.Tx:
+			return nil, errors.New("rem: FetchRelated can't be used with Iter on a *sql.Tx or *sql.Conn, because prefetching would query the connection while the cursor's result set is open. Use All instead")
+		}
+	}
+
+	queryString, args, err := query.dialect.BuildSelect(query.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := query.dbQuery(db, queryString, args...)
+	if err != nil {
+		return nil, err
+	}
+	query.Rows = rows
+	return &Cursor[T]{db: db, query: query}, nil
+}
+
 func (query *Query[T]) Join(table string, clauses ...interface{}) *Query[T] {
 	flat := make([]FilterClause, 0)
 	for _, clause := range clauses {
@@ -425,131 +891,133 @@ func (query *Query[T]) Limit(limit interface{}) *Query[T] {
 	return query
 }
 
+func (query *Query[T]) NoWait() *Query[T] {
+	query.Config.NoWait = true
+	query.Config.SkipLocked = false
+	return query
+}
+
 func (query *Query[T]) Offset(offset interface{}) *Query[T] {
 	query.Config.Offset = offset
 	return query
 }
 
+func (query *Query[T]) queryContext() context.Context {
+	if query.Config.Context != nil {
+		return query.Config.Context
+	}
+	return context.Background()
+}
+
+func (query *Query[T]) SchemaDiff(schema *TableSchema) (*TableDiff, error) {
+	expected, err := query.TableSchema()
+	if err != nil {
+		return nil, err
+	}
+	diff := &TableDiff{
+		ColumnMismatches:   make([]ColumnDiff, 0),
+		ExtraColumns:       make([]string, 0),
+		MissingColumns:     make([]string, 0),
+		MissingForeignKeys: make([]ForeignKeySchema, 0),
+		MissingIndexes:     make([]Index, 0),
+		Table:              expected.Name,
+	}
+	if schema == nil {
+		diff.Missing = true
+		return diff, nil
+	}
+
+	// Columns.
+	for _, column := range expected.Columns {
+		actual, ok := schema.Column(column.Name)
+		if !ok {
+			diff.MissingColumns = append(diff.MissingColumns, column.Name)
+			continue
+		}
+		if normalizeColumnType(column.Type) != normalizeColumnType(actual.Type) || column.Nullable != actual.Nullable {
+			diff.ColumnMismatches = append(diff.ColumnMismatches, ColumnDiff{
+				ActualNullable:   actual.Nullable,
+				ActualType:       actual.Type,
+				Column:           column.Name,
+				ExpectedNullable: column.Nullable,
+				ExpectedType:     column.Type,
+			})
+		}
+	}
+	for _, column := range schema.Columns {
+		if _, ok := expected.Column(column.Name); !ok {
+			diff.ExtraColumns = append(diff.ExtraColumns, column.Name)
+		}
+	}
+
+	// Foreign keys.
+	for _, foreignKey := range expected.ForeignKeys {
+		found := false
+		for _, actual := range schema.ForeignKeys {
+			if foreignKeyEqual(foreignKey, actual) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			diff.MissingForeignKeys = append(diff.MissingForeignKeys, foreignKey)
+		}
+	}
+
+	// Indexes.
+	for _, index := range expected.Indexes {
+		found := false
+		for _, actual := range schema.Indexes {
+			if actual.Name == index.Name && slices.Equal(actual.Columns, index.Columns) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			diff.MissingIndexes = append(diff.MissingIndexes, index)
+		}
+	}
+
+	return diff, nil
+}
+
 func (query *Query[T]) Select(columns ...interface{}) *Query[T] {
 	query.Config.Selected = columns
 	return query
 }
 
-func (query *Query[T]) slice(db *sql.DB) ([]*T, error) {
+func (query *Query[T]) SkipLocked() *Query[T] {
+	query.Config.NoWait = false
+	query.Config.SkipLocked = true
+	return query
+}
+
+func (query *Query[T]) slice(db Executor) ([]*T, error) {
 	rows := make([]*T, 0)
 	if query.Error != nil {
 		return rows, query.Error
 	}
 	defer query.Rows.Close()
 
-	relatedPks := make(map[string]relatedPk)
+	columns, err := query.Rows.Columns()
+	if err != nil {
+		return rows, err
+	}
+	scanner, err := newRowScanner(query.Model, columns)
+	if err != nil {
+		return rows, err
+	}
+
 	for query.Rows.Next() {
-		row, err := query.Model.Scan(query.Rows)
+		row, err := scanner.scan(query.Rows)
 		if err != nil {
 			return rows, err
 		}
-		if len(query.Config.FetchRelated) > 0 {
-			value := reflect.ValueOf(*row)
-			for _, column := range query.Config.FetchRelated {
-				valueFk := value.FieldByName(column)
-				if !valueFk.IsValid() {
-					return rows, fmt.Errorf("rem: invalid field '%s' for fetching related. Field does not exist on model", column)
-				}
-				if strings.HasPrefix(valueFk.Type().String(), "rem.ForeignKey[") || strings.HasPrefix(valueFk.Type().String(), "rem.NullForeignKey[") {
-					if valueFk.FieldByName("Valid").Interface().(bool) {
-						r := reflect.New(valueFk.Type()).MethodByName("Model").Call(nil)
-						rpk, ok := relatedPks[column]
-						if !ok {
-							rpk = relatedPk{
-								RelatedColumn: reflect.Indirect(r[0]).FieldByName("PrimaryColumn").Interface().(string),
-								RelatedField:  reflect.Indirect(r[0]).FieldByName("PrimaryField").Interface().(string),
-								RelatedValues: make([]interface{}, 0),
-							}
-						}
-						rpk.RelatedValues = append(rpk.RelatedValues, valueFk.FieldByName("Row").Elem().FieldByName(rpk.RelatedField).Interface())
-						relatedPks[column] = rpk
-					}
-				} else if strings.HasPrefix(valueFk.Type().String(), "rem.OneToMany[") {
-					rpk, ok := relatedPks[column]
-					if !ok {
-						relatedColumn := valueFk.FieldByName("RelatedColumn").Interface().(string)
-						r := reflect.New(valueFk.Type()).MethodByName("Model").Call(nil)
-						fkModelFields := reflect.Indirect(r[0]).FieldByName("Fields").MapRange()
-						var relatedField string
-						for fkModelFields.Next() {
-							fkModelField := fkModelFields.Value().FieldByName("Tag").MethodByName("Get").Call([]reflect.Value{reflect.ValueOf("db")})[0].Interface().(string)
-							if fkModelField == relatedColumn {
-								relatedField = fkModelFields.Value().FieldByName("Name").Interface().(string)
-								break
-							}
-						}
-
-						if relatedField == "" {
-							return rows, fmt.Errorf("rem: invalid db tag of '%s' for fetching related on field '%s'. No fields with a matching column exist on the related model", relatedColumn, column)
-						}
-
-						rpk = relatedPk{
-							RelatedColumn: relatedColumn,
-							RelatedField:  relatedField,
-							RelatedValues: make([]interface{}, 0),
-						}
-					}
-					rpk.RelatedValues = append(rpk.RelatedValues, value.FieldByName(query.Model.PrimaryField).Interface())
-					relatedPks[column] = rpk
-				} else {
-					return rows, fmt.Errorf("rem: invalid field '%s' for fetching related. Field must be of type rem.ForeignKey[To], rem.NullForeignKey[To], or rem.OneToMany[To, From]", column)
-				}
-			}
-		}
 		rows = append(rows, row)
 	}
 
-	if len(relatedPks) > 0 {
-		var temp T
-		modelValue := reflect.ValueOf(&temp).Elem()
-
-		for column, rpk := range relatedPks {
-			if len(rpk.RelatedValues) > 0 {
-				fk := reflect.New(modelValue.FieldByName(column).Type())
-
-				q := fk.MethodByName("Query").Call(nil)
-				q = q[0].MethodByName("Filter").Call([]reflect.Value{
-					reflect.ValueOf(rpk.RelatedColumn),
-					reflect.ValueOf("IN"),
-					reflect.ValueOf(rpk.RelatedValues),
-				})
-				q = q[0].MethodByName("All").Call([]reflect.Value{
-					reflect.ValueOf(db),
-				})
-				rowsValue := reflect.ValueOf(rows)
-				for i := 0; i < rowsValue.Len(); i++ {
-					value := rowsValue.Index(i).Elem()
-					valueFk := value.FieldByName(column)
-
-					if strings.HasPrefix(fk.Type().String(), "*rem.OneToMany[") {
-						mq := fk.MethodByName("Model").Call(nil)
-						fkPrimaryField := reflect.Indirect(mq[0]).FieldByName("PrimaryField").Interface().(string)
-						for j := 0; j < q[0].Len(); j++ {
-							fkRow := q[0].Index(j).Elem()
-							relatedFieldId := fkRow.FieldByName(rpk.RelatedField).FieldByName("Row").Elem().FieldByName(fkPrimaryField).Interface()
-							if value.FieldByName(query.Model.PrimaryField).Interface() == relatedFieldId {
-								valueFk.FieldByName("Rows").Set(reflect.Append(valueFk.FieldByName("Rows"), fkRow.Addr()))
-							}
-						}
-					} else if valueFk.FieldByName("Valid").Interface().(bool) {
-						mq := fk.MethodByName("Model").Call(nil)
-						fkPrimaryField := reflect.Indirect(mq[0]).FieldByName("PrimaryField").Interface().(string)
-						for j := 0; j < q[0].Len(); j++ {
-							fkRow := q[0].Index(j)
-							if valueFk.FieldByName("Row").Elem().FieldByName(query.Model.PrimaryField).Interface() == fkRow.Elem().FieldByName(fkPrimaryField).Interface() {
-								valueFk.FieldByName("Row").Set(fkRow)
-								break
-							}
-						}
-					}
-				}
-			}
-		}
+	if err := query.fetchRelated(db, rows); err != nil {
+		return rows, err
 	}
 
 	if query.Config.Context != nil {
@@ -570,14 +1038,18 @@ func (query *Query[T]) Sort(columns ...string) *Query[T] {
 
 func (query Query[T]) StringWithArgs(dialect Dialect, args []interface{}) (string, []interface{}, error) {
 	query.dialect = dialect
-	query.configure()
+	if err := query.configure(); err != nil {
+		return "", nil, err
+	}
 	query.Config.Params = args
 	return query.dialect.BuildSelect(query.Config)
 }
 
-func (query *Query[T]) TableColumnAdd(db *sql.DB, column string) (sql.Result, error) {
+func (query *Query[T]) TableColumnAdd(db Executor, column string) (sql.Result, error) {
 	query.detectDialect()
-	query.configure()
+	if err := query.configure(); err != nil {
+		return nil, err
+	}
 	queryString, err := query.dialect.BuildTableColumnAdd(query.Config, column)
 	if err != nil {
 		return nil, err
@@ -585,9 +1057,33 @@ func (query *Query[T]) TableColumnAdd(db *sql.DB, column string) (sql.Result, er
 	return query.dbExec(db, queryString)
 }
 
-func (query *Query[T]) TableColumnDrop(db *sql.DB, column string) (sql.Result, error) {
+func (query *Query[T]) TableColumnAlter(db Executor, column string) (sql.Result, error) {
 	query.detectDialect()
-	query.configure()
+	if err := query.configure(); err != nil {
+		return nil, err
+	}
+	query.Config.Fields = query.tableColumnFields()
+	if alterer, ok := query.dialect.(TableColumnAlterer); ok {
+		return alterer.AlterTableColumn(query.queryContext(), query.executor(db), query.Config, column)
+	}
+	queryStrings, err := query.dialect.BuildTableColumnAlter(query.Config, column)
+	if err != nil {
+		return nil, err
+	}
+	var result sql.Result
+	for _, queryString := range queryStrings {
+		if result, err = query.dbExec(db, queryString); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (query *Query[T]) TableColumnDrop(db Executor, column string) (sql.Result, error) {
+	query.detectDialect()
+	if err := query.configure(); err != nil {
+		return nil, err
+	}
 	queryString, err := query.dialect.BuildTableColumnDrop(query.Config, column)
 	if err != nil {
 		return nil, err
@@ -595,27 +1091,90 @@ func (query *Query[T]) TableColumnDrop(db *sql.DB, column string) (sql.Result, e
 	return query.dbExec(db, queryString)
 }
 
-func (query *Query[T]) TableCreate(db *sql.DB, tableCreateConfig ...TableCreateConfig) (sql.Result, error) {
+func (query *Query[T]) TableColumnRename(db Executor, from string, to string) (sql.Result, error) {
 	query.detectDialect()
-	query.configure()
+	if err := query.configure(); err != nil {
+		return nil, err
+	}
+	queryString, err := query.dialect.BuildTableColumnRename(query.Config, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return query.dbExec(db, queryString)
+}
+
+func (query *Query[T]) tableColumnFields() map[string]reflect.StructField {
+	// Relation fields are not columns.
+	meta := query.Model.metadata()
+	fields := make(map[string]reflect.StructField)
+	for column, field := range query.Config.Fields {
+		if fieldMeta, ok := meta.byColumn[column]; !ok || !fieldMeta.isRelation() {
+			fields[column] = field
+		}
+	}
+	return fields
+}
+
+func (query *Query[T]) TableCreate(db Executor, tableCreateConfig ...TableCreateConfig) (sql.Result, error) {
+	query.detectDialect()
+	if err := query.configure(); err != nil {
+		return nil, err
+	}
 	var config TableCreateConfig
 	if len(tableCreateConfig) > 0 {
 		config = tableCreateConfig[0]
 	}
+
+	query.Config.Fields = query.tableColumnFields()
 	queryString, err := query.dialect.BuildTableCreate(query.Config, config)
 	if err != nil {
 		return nil, err
 	}
-	return query.dbExec(db, queryString)
+	result, err := query.dbExec(db, queryString)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Indexes {
+		indexConfig := TableIndexCreateConfig{IfNotExists: config.IfNotExists}
+		existing := make(map[string]bool)
+		if introspector, ok := query.dialect.(Introspector); ok && config.IfNotExists {
+			// Not every dialect supports CREATE INDEX IF NOT EXISTS, so existing indexes are skipped instead.
+			schema, err := introspector.IntrospectTable(query.queryContext(), query.executor(db), query.Config.Table)
+			if err != nil {
+				return nil, err
+			}
+			for _, index := range schema.Indexes {
+				existing[index.Name] = true
+			}
+			indexConfig.IfNotExists = false
+		}
+		for _, index := range query.Config.Indexes {
+			if existing[index.Name] {
+				continue
+			}
+			queryString, err := query.dialect.BuildTableIndexCreate(query.Config, index.Name, indexConfig)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := query.dbExec(db, queryString); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
 }
 
-func (query *Query[T]) TableDrop(db *sql.DB, tableDropConfig ...TableDropConfig) (sql.Result, error) {
+func (query *Query[T]) TableDrop(db Executor, tableDropConfig ...TableDropConfig) (sql.Result, error) {
 	query.detectDialect()
-	query.configure()
+	if err := query.configure(); err != nil {
+		return nil, err
+	}
 	var config TableDropConfig
 	if len(tableDropConfig) > 0 {
 		config = tableDropConfig[0]
 	}
+
 	queryString, err := query.dialect.BuildTableDrop(query.Config, config)
 	if err != nil {
 		return nil, err
@@ -623,14 +1182,168 @@ func (query *Query[T]) TableDrop(db *sql.DB, tableDropConfig ...TableDropConfig)
 	return query.dbExec(db, queryString)
 }
 
+func (query *Query[T]) TableIndexCreate(db Executor, name string, tableIndexCreateConfig ...TableIndexCreateConfig) (sql.Result, error) {
+	query.detectDialect()
+	if err := query.configure(); err != nil {
+		return nil, err
+	}
+	var config TableIndexCreateConfig
+	if len(tableIndexCreateConfig) > 0 {
+		config = tableIndexCreateConfig[0]
+	}
+	queryString, err := query.dialect.BuildTableIndexCreate(query.Config, name, config)
+	if err != nil {
+		return nil, err
+	}
+	return query.dbExec(db, queryString)
+}
+
+func (query *Query[T]) TableIndexDrop(db Executor, name string, tableIndexDropConfig ...TableIndexDropConfig) (sql.Result, error) {
+	query.detectDialect()
+	if err := query.configure(); err != nil {
+		return nil, err
+	}
+	var config TableIndexDropConfig
+	if len(tableIndexDropConfig) > 0 {
+		config = tableIndexDropConfig[0]
+	}
+	queryString, err := query.dialect.BuildTableIndexDrop(query.Config, name, config)
+	if err != nil {
+		return nil, err
+	}
+	return query.dbExec(db, queryString)
+}
+
+func (query *Query[T]) TableIntrospect(db Executor) (*TableSchema, error) {
+	query.detectDialect()
+	if err := query.configure(); err != nil {
+		return nil, err
+	}
+	introspector, ok := query.dialect.(Introspector)
+	if !ok {
+		return nil, fmt.Errorf("rem: dialect '%T' does not support introspection", query.dialect)
+	}
+	return introspector.IntrospectTable(query.queryContext(), query.executor(db), query.Config.Table)
+}
+
+func (query *Query[T]) TableRename(db Executor, name string) (sql.Result, error) {
+	query.detectDialect()
+	if err := query.configure(); err != nil {
+		return nil, err
+	}
+	queryString, err := query.dialect.BuildTableRename(query.Config, name)
+	if err != nil {
+		return nil, err
+	}
+	return query.dbExec(db, queryString)
+}
+
+func (query *Query[T]) TableSchema() (*TableSchema, error) {
+	query.detectDialect()
+	if err := query.configure(); err != nil {
+		return nil, err
+	}
+	schema := &TableSchema{
+		Columns:     make([]ColumnSchema, 0),
+		ForeignKeys: make([]ForeignKeySchema, 0),
+		Indexes:     make([]Index, 0, len(query.Config.Indexes)),
+		Name:        query.Config.Table,
+		PrimaryKey:  make([]string, 0, len(query.Config.PrimaryColumns)),
+	}
+	schema.Indexes = append(schema.Indexes, query.Config.Indexes...)
+	schema.PrimaryKey = append(schema.PrimaryKey, query.Config.PrimaryColumns...)
+
+	// Columns.
+	meta := query.Model.metadata()
+	columns := make([]string, 0, len(query.Config.Fields))
+	for column := range query.Config.Fields {
+		if fieldMeta, ok := meta.byColumn[column]; !ok || !fieldMeta.isRelation() {
+			columns = append(columns, column)
+		}
+	}
+	sort.Strings(columns)
+	for _, column := range columns {
+		field := query.Config.Fields[column]
+		columnType, err := query.columnType(field)
+		if err != nil {
+			return nil, err
+		}
+		expectedType, nullable := parseColumnType(columnType)
+		upperType := strings.ToUpper(columnType)
+		schema.Columns = append(schema.Columns, ColumnSchema{
+			AutoIncrement: strings.Contains(upperType, "SERIAL") || strings.Contains(upperType, "AUTO_INCREMENT") || strings.Contains(upperType, "AUTOINCREMENT"),
+			Default:       sql.NullString{String: field.Tag.Get("db_default"), Valid: field.Tag.Get("db_default") != ""},
+			Name:          column,
+			Nullable:      nullable,
+			Type:          expectedType,
+		})
+	}
+
+	// Foreign keys.
+	foreignKeys := make(map[string]*ForeignKeySchema)
+	for _, column := range columns {
+		field, ok := meta.byColumn[column]
+		if !ok || !field.isForeignKey() {
+			continue
+		}
+		foreignKey, ok := foreignKeys[field.name]
+		if !ok {
+			relatedModel := reflect.New(field.structField.Type).MethodByName("Model").Call(nil)
+			foreignKey = &ForeignKeySchema{
+				OnDelete:        field.structField.Tag.Get("db_on_delete"),
+				OnUpdate:        field.structField.Tag.Get("db_on_update"),
+				ReferencedTable: reflect.Indirect(relatedModel[0]).FieldByName("Table").Interface().(string),
+			}
+			foreignKeys[field.name] = foreignKey
+		}
+		foreignKey.Columns = append(foreignKey.Columns, column)
+		foreignKey.ReferencedColumns = append(foreignKey.ReferencedColumns, field.relatedPrimaryColumn)
+	}
+	foreignKeyNames := maps.Keys(foreignKeys)
+	sort.Strings(foreignKeyNames)
+	for _, name := range foreignKeyNames {
+		schema.ForeignKeys = append(schema.ForeignKeys, *foreignKeys[name])
+	}
+
+	return schema, nil
+}
+
+func (query *Query[T]) TableThroughCreate(db Executor, field string, tableCreateConfig ...TableCreateConfig) (sql.Result, error) {
+	throughQuery, err := query.throughQuery(field)
+	if err != nil {
+		return nil, err
+	}
+	return throughQuery.TableCreate(db, tableCreateConfig...)
+}
+
+func (query *Query[T]) TableThroughDrop(db Executor, field string, tableDropConfig ...TableDropConfig) (sql.Result, error) {
+	throughQuery, err := query.throughQuery(field)
+	if err != nil {
+		return nil, err
+	}
+	return throughQuery.TableDrop(db, tableDropConfig...)
+}
+
+func (query *Query[T]) throughQuery(field string) (*Query[manyToManyThrough], error) {
+	query.detectDialect()
+	structField, ok := query.Model.Fields[field]
+	if !ok || query.Model.metadata().byColumn[field].kind != fieldManyToMany {
+		return nil, fmt.Errorf("rem: invalid field '%s' for join table. Field must be of type rem.ManyToMany[To]", field)
+	}
+	throughModel := newManyToManyField(structField).throughModel(reflect.TypeOf(ForeignKey[T]{}))
+	return &Query[manyToManyThrough]{Config: QueryConfig{Context: query.Config.Context, Transaction: query.Config.Transaction}, Model: throughModel, dialect: query.dialect}, nil
+}
+
 func (query *Query[T]) Transaction(transaction *sql.Tx) *Query[T] {
 	query.Config.Transaction = transaction
 	return query
 }
 
-func (query *Query[T]) Update(db *sql.DB, row *T, columns ...string) (sql.Result, error) {
+func (query *Query[T]) Update(db Executor, row *T, columns ...string) (sql.Result, error) {
 	query.detectDialect()
-	query.configure()
+	if err := query.configure(); err != nil {
+		return nil, err
+	}
 
 	if len(columns) == 0 {
 		return nil, fmt.Errorf("rem: no columns specified for update")
@@ -641,16 +1354,24 @@ func (query *Query[T]) Update(db *sql.DB, row *T, columns ...string) (sql.Result
 		return nil, err
 	}
 
+	if supportsReturning(query.dialect) {
+		query.Config.Returning = query.Model.returningColumns()
+	}
 	queryString, args, err := query.dialect.BuildUpdate(query.Config, rowMap, columns...)
 	if err != nil {
 		return nil, err
 	}
+	if len(query.Config.Returning) > 0 {
+		return query.dbReturning(db, row, queryString, args...)
+	}
 	return query.dbExec(db, queryString, args...)
 }
 
-func (query *Query[T]) UpdateMap(db *sql.DB, data map[string]interface{}) (sql.Result, error) {
+func (query *Query[T]) UpdateMap(db Executor, data map[string]interface{}) (sql.Result, error) {
 	query.detectDialect()
-	query.configure()
+	if err := query.configure(); err != nil {
+		return nil, err
+	}
 
 	if len(data) == 0 {
 		return nil, fmt.Errorf("rem: no columns specified for update")
@@ -668,8 +1389,95 @@ func (query *Query[T]) UpdateMap(db *sql.DB, data map[string]interface{}) (sql.R
 	return query.dbExec(db, queryString, args...)
 }
 
+func (query *Query[T]) Upsert(db Executor, row *T, conflictColumns []string, updateColumns ...string) (sql.Result, error) {
+	if len(updateColumns) == 0 {
+		return nil, fmt.Errorf("rem: no columns specified for upsert")
+	}
+	return query.upsert(db, row, UpsertConfig{
+		ConflictColumns: conflictColumns,
+		UpdateColumns:   updateColumns,
+	})
+}
+
+func (query *Query[T]) upsert(db Executor, row *T, upsertConfig UpsertConfig) (sql.Result, error) {
+	query.detectDialect()
+	if err := query.configure(); err != nil {
+		return nil, err
+	}
+	rowMap, err := query.Model.ToMap(row)
+	if err != nil {
+		return nil, err
+	}
+	if supportsReturning(query.dialect) {
+		query.Config.Returning = query.Model.returningColumns()
+	}
+	columns := maps.Keys(rowMap)
+	sort.Strings(columns)
+	queryString, args, err := query.dialect.BuildUpsert(query.Config, rowMap, upsertConfig, columns...)
+	if err != nil {
+		return nil, err
+	}
+	return query.insertRow(db, row, rowMap, queryString, args...)
+}
+
+func (query *Query[T]) UpsertDoNothing(db Executor, row *T, conflictColumns ...string) (sql.Result, error) {
+	return query.upsert(db, row, UpsertConfig{
+		ConflictColumns: conflictColumns,
+		DoNothing:       true,
+	})
+}
+
+type batchResult struct {
+	results []sql.Result
+}
+
+func (result *batchResult) LastInsertId() (int64, error) {
+	if len(result.results) == 0 {
+		return 0, fmt.Errorf("rem: no batches were executed")
+	}
+	return result.results[len(result.results)-1].LastInsertId()
+}
+
+func (result *batchResult) RowsAffected() (int64, error) {
+	var total int64
+	for _, batch := range result.results {
+		affected, err := batch.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += affected
+	}
+	return total, nil
+}
+
 type relatedPk struct {
-	RelatedColumn string
-	RelatedField  string
-	RelatedValues []interface{}
+	RelatedColumn  string
+	RelatedColumns []string
+	RelatedField   string
+	RelatedValues  []interface{}
+}
+
+type returningResult struct {
+	lastInsertId interface{}
+	rowsAffected int64
+}
+
+func (result *returningResult) LastInsertId() (int64, error) {
+	switch id := result.lastInsertId.(type) {
+	case int:
+		return int64(id), nil
+	case int8:
+		return int64(id), nil
+	case int16:
+		return int64(id), nil
+	case int32:
+		return int64(id), nil
+	case int64:
+		return id, nil
+	}
+	return 0, fmt.Errorf("rem: LastInsertId is not supported for primary key '%#v'", result.lastInsertId)
+}
+
+func (result *returningResult) RowsAffected() (int64, error) {
+	return result.rowsAffected, nil
 }
@@ -1,8 +1,10 @@
 package rem
 
 import (
+	"errors"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"golang.org/x/exp/maps"
@@ -17,7 +19,9 @@ func TestQueryConfigure(t *testing.T) {
 	}
 
 	query := Use[testModel]().Query()
-	query.configure()
+	if err := query.configure(); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
 	columns := maps.Keys(query.Config.Fields)
 	sort.Strings(columns)
 	expectedColumns := []string{"test_id", "test_value_1", "test_value_2"}
@@ -46,6 +50,263 @@ func TestQueryDetectDialect(t *testing.T) {
 	query.detectDialect()
 }
 
+type testUsersFetchNested struct {
+	Id   int64  `db:"id" db_primary:"true"`
+	Name string `db:"name"`
+}
+type testGroupsFetchNested struct {
+	Accounts OneToMany[testAccountsFetchNested] `db:"group_id"`
+	Id       int64                              `db:"id" db_primary:"true"`
+	Owner    ForeignKey[testUsersFetchNested]   `db:"owner_id"`
+}
+type testAccountsFetchNested struct {
+	Group ForeignKey[testGroupsFetchNested] `db:"group_id"`
+	Id    int64                             `db:"id" db_primary:"true"`
+	Name  string                            `db:"name"`
+}
+
+type testPairsFetchComposite struct {
+	A    int64  `db:"a" db_primary:"true"`
+	B    string `db:"b" db_primary:"true"`
+	Name string `db:"name"`
+}
+
+type testEventsFetchComposite struct {
+	Id   int64                               `db:"id" db_primary:"true"`
+	Pair ForeignKey[testPairsFetchComposite] `db:"pair_a,pair_b"`
+}
+
+func TestQueryFetchRelatedComposite(t *testing.T) {
+	defer func() {
+		defaultDialect = nil
+	}()
+	SetDialect(testDialect{})
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	pairs := Use[testPairsFetchComposite]()
+	if !slices.Equal(pairs.PrimaryColumns, []string{"a", "b"}) || !slices.Equal(pairs.PrimaryFields, []string{"A", "B"}) {
+		t.Errorf("Expected composite primary key, got '%+v' and '%+v'", pairs.PrimaryColumns, pairs.PrimaryFields)
+	}
+
+	mock.ExpectQuery("SELECT|FILTER[]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "pair_a", "pair_b"}).
+			AddRow(1, 10, "x").
+			AddRow(2, 10, "y"))
+	mock.ExpectQuery("SELECT|FILTER[{Left:<nil> Operator: Right:<nil> Rule:(} {Left:<nil> Operator: Right:<nil> Rule:(} {Left:a Operator:= Right:10 Rule:WHERE} {Left:<nil> Operator: Right:<nil> Rule:AND} {Left:b Operator:= Right:x Rule:WHERE} {Left:<nil> Operator: Right:<nil> Rule:)} {Left:<nil> Operator: Right:<nil> Rule:OR} {Left:<nil> Operator: Right:<nil> Rule:(} {Left:a Operator:= Right:10 Rule:WHERE} {Left:<nil> Operator: Right:<nil> Rule:AND} {Left:b Operator:= Right:y Rule:WHERE} {Left:<nil> Operator: Right:<nil> Rule:)} {Left:<nil> Operator: Right:<nil> Rule:)}]|").
+		WillReturnRows(sqlmock.NewRows([]string{"a", "b", "name"}).
+			AddRow(10, "y", "Pair 10y").
+			AddRow(10, "x", "Pair 10x"))
+	events, err := Use[testEventsFetchComposite]().FetchRelated("Pair").All(db)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(events) != 2 ||
+		events[0].Pair.Row.Name != "Pair 10x" ||
+		events[1].Pair.Row.Name != "Pair 10y" {
+		t.Errorf("Expected pairs matched on both columns, got '%+v'", events)
+	}
+
+	args, err := Use[testEventsFetchComposite]().ToMap(events[1])
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(args) != 3 || args["id"] != int64(2) || args["pair_a"] != int64(10) || args["pair_b"] != "y" {
+		t.Errorf("Expected composite foreign key columns, got '%+v'", args)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+type testEventsInvalidComposite struct {
+	Id   int64                               `db:"id" db_primary:"true"`
+	Pair ForeignKey[testPairsFetchComposite] `db:"pair_a"`
+}
+
+func TestQueryInvalidComposite(t *testing.T) {
+	defer func() {
+		defaultDialect = nil
+	}()
+	SetDialect(testDialect{})
+
+	model := Use[testEventsInvalidComposite]()
+	expected := "rem: foreign key field 'Pair' has 1 columns, but 'rem.testPairsFetchComposite' has 2 primary key columns"
+	if model.Error == nil || model.Error.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, model.Error)
+	}
+	if _, err := model.All(nil); err != model.Error {
+		t.Errorf("Expected '%s', got '%v'", expected, err)
+	}
+}
+
+type testEventsPrimaryComposite struct {
+	Name string                              `db:"name"`
+	Pair ForeignKey[testPairsFetchComposite] `db:"pair_a,pair_b" db_primary:"true"`
+}
+
+func TestQueryPrimaryComposite(t *testing.T) {
+	model := Use[testEventsPrimaryComposite]()
+	expected := "rem: composite foreign key field 'Pair' can't be part of the primary key. Declare a field for each column instead"
+	if model.Error == nil || model.Error.Error() != expected {
+		t.Errorf("Expected '%s', got '%v'", expected, model.Error)
+	}
+}
+
+func TestQueryFetchRelatedNested(t *testing.T) {
+	defer func() {
+		defaultDialect = nil
+	}()
+	SetDialect(testDialect{})
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	// Foreign key to foreign key.
+	mock.ExpectQuery("SELECT|FILTER[]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "group_id"}).
+			AddRow(1, "foo", 10).
+			AddRow(2, "bar", 20))
+	mock.ExpectQuery("SELECT|FILTER[{Left:id Operator:IN Right:[10 20] Rule:WHERE}]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id"}).
+			AddRow(10, 100).
+			AddRow(20, 200))
+	mock.ExpectQuery("SELECT|FILTER[{Left:id Operator:IN Right:[100 200] Rule:WHERE}]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(100, "User 100").
+			AddRow(200, "User 200"))
+	accounts, err := Use[testAccountsFetchNested]().FetchRelated("Group.Owner").All(db)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(accounts) != 2 ||
+		accounts[0].Group.Row.Owner.Row.Name != "User 100" ||
+		accounts[1].Group.Row.Owner.Row.Name != "User 200" {
+		t.Errorf("Expected nested owners, got '%+v'", accounts)
+	}
+
+	// One to many to foreign key.
+	mock.ExpectQuery("SELECT|FILTER[]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id"}).
+			AddRow(10, 100))
+	mock.ExpectQuery("SELECT|FILTER[{Left:group_id Operator:IN Right:[10] Rule:WHERE}]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "group_id"}).
+			AddRow(1, "foo", 10).
+			AddRow(2, "bar", 10))
+	mock.ExpectQuery("SELECT|FILTER[{Left:id Operator:IN Right:[10 10] Rule:WHERE}]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id"}).
+			AddRow(10, 100))
+	mock.ExpectQuery("SELECT|FILTER[{Left:id Operator:IN Right:[100] Rule:WHERE}]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(100, "User 100"))
+	groups, err := Use[testGroupsFetchNested]().FetchRelated("Accounts.Group", "Owner").All(db)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(groups) != 1 ||
+		len(groups[0].Accounts.Rows) != 2 ||
+		groups[0].Accounts.Rows[0].Group.Row.Owner.Row.Id != 100 ||
+		groups[0].Owner.Row.Name != "User 100" {
+		t.Errorf("Expected nested accounts and owner, got '%+v'", groups)
+	}
+
+	// Invalid nested field.
+	mock.ExpectQuery("SELECT|FILTER[]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "group_id"}).
+			AddRow(1, "foo", 10))
+	mock.ExpectQuery("SELECT|FILTER[{Left:id Operator:IN Right:[10] Rule:WHERE}]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id"}).
+			AddRow(10, 100))
+	_, err = Use[testAccountsFetchNested]().FetchRelated("Group.Bogus").All(db)
+	if err == nil || err.Error() != "rem: invalid field 'Bogus' for fetching related. Field does not exist on model" {
+		t.Errorf("Expected invalid field error, got '%v'", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestQueryFetchRelatedQuery(t *testing.T) {
+	defer func() {
+		defaultDialect = nil
+	}()
+	SetDialect(testDialect{})
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT|FILTER[]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id"}).
+			AddRow(10, 100))
+	mock.ExpectQuery("SELECT|FILTER[{Left:name Operator:!= Right:baz Rule:WHERE} {Left:<nil> Operator: Right:<nil> Rule:AND} {Left:group_id Operator:IN Right:[10] Rule:WHERE}]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "group_id"}).
+			AddRow(2, "bar", 10).
+			AddRow(1, "foo", 10))
+	mock.ExpectQuery("SELECT|FILTER[{Left:id Operator:> Right:0 Rule:WHERE} {Left:<nil> Operator: Right:<nil> Rule:AND} {Left:id Operator:IN Right:[10 10] Rule:WHERE}]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id"}).
+			AddRow(10, 100))
+	groups, err := Use[testGroupsFetchNested]().
+		FetchRelatedQuery("Accounts", func(q *Query[testAccountsFetchNested]) *Query[testAccountsFetchNested] {
+			return q.Filter("name", "!=", "baz").Sort("-id")
+		}).
+		FetchRelatedQuery("Accounts.Group", func(q *Query[testGroupsFetchNested]) *Query[testGroupsFetchNested] {
+			return q.Filter("id", ">", 0)
+		}).
+		All(db)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if len(groups) != 1 ||
+		len(groups[0].Accounts.Rows) != 2 ||
+		groups[0].Accounts.Rows[0].Id != 2 ||
+		groups[0].Accounts.Rows[1].Id != 1 ||
+		groups[0].Accounts.Rows[0].Group.Row.Id != 10 {
+		t.Errorf("Expected ordered accounts, got '%+v'", groups)
+	}
+
+	// Invalid query function.
+	mock.ExpectQuery("SELECT|FILTER[]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id"}).
+			AddRow(10, 100))
+	_, err = Use[testGroupsFetchNested]().
+		FetchRelatedQuery("Accounts", func(q *Query[testUsersFetchNested]) *Query[testUsersFetchNested] {
+			return q
+		}).
+		All(db)
+	if err == nil || err.Error() != "rem: invalid query function for fetching related on field 'Accounts'. Expected func(*rem.Query[github.com/evantbyrne/rem.testAccountsFetchNested]) *rem.Query[github.com/evantbyrne/rem.testAccountsFetchNested]" {
+		t.Errorf("Expected invalid query function error, got '%v'", err)
+	}
+
+	// Limit would truncate the related rows of all groups together.
+	mock.ExpectQuery("SELECT|FILTER[]|").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id"}).
+			AddRow(10, 100))
+	_, err = Use[testGroupsFetchNested]().
+		FetchRelatedQuery("Accounts", func(q *Query[testAccountsFetchNested]) *Query[testAccountsFetchNested] {
+			return q.Limit(1)
+		}).
+		All(db)
+	if err == nil || err.Error() != "rem: Limit and Offset can't be used in the query function for fetching related on field 'Accounts', because they would apply to all parent rows together" {
+		t.Errorf("Expected Limit error, got '%v'", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestQueryFilters(t *testing.T) {
 	type testModel struct {
 		Id     int64  `db:"test_id" db_primary:"true"`
@@ -128,6 +389,253 @@ func TestQueryFilters(t *testing.T) {
 	}
 }
 
+func TestQueryHaving(t *testing.T) {
+	type testModel struct {
+		Id     int64  `db:"test_id" db_primary:"true"`
+		Amount int64  `db:"amount"`
+		Group  string `db:"group_name" db_max_length:"100"`
+	}
+
+	query := Use[testModel]().GroupBy("group_name").Having(Q(Sum("amount"), ">", 100))
+	if !slices.Equal(query.Config.GroupBy, []interface{}{"group_name"}) {
+		t.Errorf(`Expected '%+v', got '%+v'`, []interface{}{"group_name"}, query.Config.GroupBy)
+	}
+	expected := []FilterClause{
+		{Rule: "("},
+		{Left: Sum("amount"), Operator: ">", Right: 100, Rule: "WHERE"},
+		{Rule: ")"},
+	}
+	if !slices.Equal(query.Config.Having, expected) {
+		t.Errorf(`Expected '%+v', got '%+v'`, expected, query.Config.Having)
+	}
+
+	query = query.Having(Or(
+		Q(Count("*"), ">", 1),
+		Q(Min("amount"), "<", 0),
+	))
+	expected = []FilterClause{
+		{Rule: "("},
+		{Left: Sum("amount"), Operator: ">", Right: 100, Rule: "WHERE"},
+		{Rule: ")"},
+		{Rule: "AND"},
+		{Rule: "("},
+		{Rule: "("},
+		{Left: Count("*"), Operator: ">", Right: 1, Rule: "WHERE"},
+		{Rule: "OR"},
+		{Left: Min("amount"), Operator: "<", Right: 0, Rule: "WHERE"},
+		{Rule: ")"},
+		{Rule: ")"},
+	}
+	if !slices.Equal(query.Config.Having, expected) {
+		t.Errorf(`Expected '%+v', got '%+v'`, expected, query.Config.Having)
+	}
+	if len(query.Config.Filters) != 0 {
+		t.Errorf(`Expected no filters, got '%+v'`, query.Config.Filters)
+	}
+}
+
+func TestQueryInsert(t *testing.T) {
+	type testModel struct {
+		CreatedAt time.Time `db:"created_at" db_default:"now()"`
+		Id        int64     `db:"id" db_primary:"true"`
+		Name      string    `db:"name"`
+	}
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	// LastInsertId fallback.
+	mock.ExpectExec("INSERT|testmodel|created_at,name|RETURNING|").
+		WithArgs(time.Time{}, "foo").
+		WillReturnResult(sqlmock.NewResult(42, 1))
+	row := &testModel{Name: "foo"}
+	if _, err := Use[testModel]().Dialect(testDialect{}).Insert(db, row); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if row.Id != 42 || row.Name != "foo" {
+		t.Errorf(`Expected '%+v', got '%+v'`, testModel{Id: 42, Name: "foo"}, *row)
+	}
+
+	// Dialects are only asked for RETURNING when they implement ReturningSupporter.
+	mock.ExpectExec("INSERT|testmodel|created_at,name|RETURNING|").
+		WithArgs(time.Time{}, "baz").
+		WillReturnResult(sqlmock.NewResult(44, 1))
+	row = &testModel{Name: "baz"}
+	if _, err := Use[testModel]().Dialect(testBasicDialect{testDialect{returning: true}}).Insert(db, row); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if row.Id != 44 {
+		t.Errorf(`Expected '%+v', got '%+v'`, testModel{Id: 44, Name: "baz"}, *row)
+	}
+
+	// RETURNING.
+	createdAt := time.Date(2009, time.January, 2, 3, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("INSERT|testmodel|created_at,name|RETURNINGcreated_at,id|").
+		WithArgs(time.Time{}, "bar").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "id"}).AddRow(createdAt, 43))
+	row = &testModel{Name: "bar"}
+	result, err := Use[testModel]().Dialect(testDialect{returning: true}).Insert(db, row)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if row.Id != 43 || row.Name != "bar" || row.CreatedAt != createdAt {
+		t.Errorf(`Expected '%+v', got '%+v'`, testModel{CreatedAt: createdAt, Id: 43, Name: "bar"}, *row)
+	}
+	if id, err := result.LastInsertId(); err != nil || id != 43 {
+		t.Errorf(`Expected LastInsertId 43, got '%d' with error '%v'`, id, err)
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected != 1 {
+		t.Errorf(`Expected RowsAffected 1, got '%d' with error '%v'`, affected, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestQueryInsertMany(t *testing.T) {
+	type testModel struct {
+		Id   int64  `db:"id" db_primary:"true"`
+		Name string `db:"name"`
+		Rank int64  `db:"rank"`
+	}
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	rows := []*testModel{
+		{Name: "a", Rank: 1},
+		{Name: "b", Rank: 2},
+		{Name: "c", Rank: 3},
+		{Name: "d", Rank: 4},
+		{Name: "e", Rank: 5},
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT|testmodel|name,rank|ROWS2|").
+		WithArgs("a", int64(1), "b", int64(2)).
+		WillReturnResult(sqlmock.NewResult(2, 2))
+	mock.ExpectExec("INSERT|testmodel|name,rank|ROWS2|").
+		WithArgs("c", int64(3), "d", int64(4)).
+		WillReturnResult(sqlmock.NewResult(4, 2))
+	mock.ExpectExec("INSERT|testmodel|name,rank|ROWS1|").
+		WithArgs("e", int64(5)).
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectCommit()
+
+	result, err := Use[testModel]().Dialect(testDialect{}).InsertMany(db, rows)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected != 5 {
+		t.Errorf(`Expected RowsAffected 5, got '%d' with error '%v'`, affected, err)
+	}
+	if id, err := result.LastInsertId(); err != nil || id != 5 {
+		t.Errorf(`Expected LastInsertId 5, got '%d' with error '%v'`, id, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// A failed batch rolls back the earlier batches.
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT|testmodel|name,rank|ROWS2|").
+		WithArgs("a", int64(1), "b", int64(2)).
+		WillReturnResult(sqlmock.NewResult(2, 2))
+	mock.ExpectExec("INSERT|testmodel|name,rank|ROWS2|").
+		WithArgs("c", int64(3), "d", int64(4)).
+		WillReturnError(errors.New("failed"))
+	mock.ExpectRollback()
+	result, err = Use[testModel]().Dialect(testDialect{}).InsertMany(db, rows)
+	if err == nil || err.Error() != "failed" {
+		t.Errorf("Expected 'failed', got '%v'", err)
+	}
+	if result != nil {
+		t.Errorf("Expected no result, got '%+v'", result)
+	}
+
+	// Batches within a transaction are left to the caller's transaction.
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT|testmodel|name,rank|ROWS2|").
+		WithArgs("a", int64(1), "b", int64(2)).
+		WillReturnResult(sqlmock.NewResult(2, 2))
+	mock.ExpectExec("INSERT|testmodel|name,rank|ROWS2|").
+		WithArgs("c", int64(3), "d", int64(4)).
+		WillReturnResult(sqlmock.NewResult(4, 2))
+	mock.ExpectExec("INSERT|testmodel|name,rank|ROWS1|").
+		WithArgs("e", int64(5)).
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectCommit()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if _, err := Use[testModel]().Dialect(testDialect{}).InsertMany(tx, rows); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	_, err = Use[testModel]().Dialect(testDialect{}).InsertMany(db, []*testModel{{Name: "a"}, {Id: 2, Name: "b"}})
+	if err == nil {
+		t.Error("Expected error for inconsistent primary keys")
+	}
+}
+
+func TestQueryUpsert(t *testing.T) {
+	type testModel struct {
+		Id    int64  `db:"id" db_primary:"true"`
+		Email string `db:"email" db_unique:"true"`
+		Name  string `db:"name"`
+	}
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("UPSERT|testmodel|email,name|{ConflictColumns:[email] DoNothing:false UpdateColumns:[name]}|RETURNINGid|").
+		WithArgs("foo@example.com", "foo").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+	row := &testModel{Email: "foo@example.com", Name: "foo"}
+	if _, err := Use[testModel]().Dialect(testDialect{returning: true}).Upsert(db, row, []string{"email"}, "name"); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if row.Id != 10 {
+		t.Errorf(`Expected '%d', got '%d'`, 10, row.Id)
+	}
+
+	mock.ExpectQuery("UPSERT|testmodel|email,name|{ConflictColumns:[email] DoNothing:true UpdateColumns:[]}|RETURNINGid|").
+		WithArgs("bar@example.com", "bar").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	row = &testModel{Email: "bar@example.com", Name: "bar"}
+	result, err := Use[testModel]().Dialect(testDialect{returning: true}).UpsertDoNothing(db, row, "email")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if affected, _ := result.RowsAffected(); affected != 0 || row.Id != 0 {
+		t.Errorf(`Expected no rows affected, got '%d' and '%+v'`, affected, *row)
+	}
+
+	if _, err := Use[testModel]().Dialect(testDialect{}).Upsert(db, row, []string{"email"}); err == nil {
+		t.Error("Expected error for upsert without update columns")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestQueryJoins(t *testing.T) {
 	type testGroups struct {
 		Id   int64  `db:"test_id" db_primary:"true"`
@@ -340,3 +848,84 @@ func TestQuerySlice(t *testing.T) {
 		}
 	}
 }
+
+func TestQueryTableAlter(t *testing.T) {
+	type testAlters struct {
+		Id   int64  `db:"id" db_primary:"true"`
+		Name string `db:"name" db_max_length:"100"`
+	}
+
+	defer func() {
+		defaultDialect = nil
+	}()
+	SetDialect(testDialect{})
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("ALTER COLUMN|testalters|name|").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RENAME COLUMN|testalters|name|title|").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RENAME|testalters|renamed|").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	model := Use[testAlters]()
+	if _, err := model.TableColumnAlter(db, "name"); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if _, err := model.TableColumnRename(db, "name", "title"); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if _, err := model.TableRename(db, "renamed"); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestQueryUpdate(t *testing.T) {
+	type testModel struct {
+		CreatedAt time.Time `db:"created_at" db_default:"now()"`
+		Id        int64     `db:"id" db_primary:"true"`
+		Name      string    `db:"name"`
+	}
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	createdAt := time.Date(2009, time.January, 2, 3, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("UPDATE|testmodel|name|FILTER[]|RETURNINGcreated_at,id|").
+		WithArgs("foo").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "id"}).AddRow(createdAt, 42))
+	row := &testModel{Name: "foo"}
+	if _, err := Use[testModel]().Dialect(testDialect{returning: true}).Update(db, row, "name"); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if row.Id != 42 || row.CreatedAt != createdAt {
+		t.Errorf(`Expected '%+v', got '%+v'`, testModel{CreatedAt: createdAt, Id: 42, Name: "foo"}, *row)
+	}
+
+	// Rows aren't written back when several rows were updated.
+	mock.ExpectQuery("UPDATE|testmodel|name|FILTER[]|RETURNINGcreated_at,id|").
+		WithArgs("bar").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "id"}).AddRow(createdAt, 42).AddRow(createdAt, 43))
+	row = &testModel{Name: "bar"}
+	result, err := Use[testModel]().Dialect(testDialect{returning: true}).Update(db, row, "name")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if *row != (testModel{Name: "bar"}) {
+		t.Errorf(`Expected '%+v', got '%+v'`, testModel{Name: "bar"}, *row)
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected != 2 {
+		t.Errorf(`Expected RowsAffected 2, got '%d' with error '%v'`, affected, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,72 @@
+package rem
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+type Registry struct {
+	models map[registryKey]interface{}
+	mutex  sync.RWMutex
+}
+
+func (registry *Registry) load(key registryKey) (interface{}, bool) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	model, ok := registry.models[key]
+	return model, ok
+}
+
+func (registry *Registry) Reset() {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.models = make(map[registryKey]interface{})
+}
+
+type registryKey struct {
+	configs   string
+	modelType reflect.Type
+}
+
+var defaultRegistry = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{
+		models: make(map[registryKey]interface{}),
+	}
+}
+
+func newRegistryKey[T any](configs []Config) registryKey {
+	key := registryKey{
+		modelType: reflect.TypeOf((*T)(nil)).Elem(),
+	}
+	if len(configs) > 0 {
+		key.configs = fmt.Sprintf("%+v", configs)
+	}
+	return key
+}
+
+func RegisterIn[T any](registry *Registry, configs ...Config) *Model[T] {
+	model := newModel[T](configs...)
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.models[newRegistryKey[T](configs)] = model
+	return model
+}
+
+func UseIn[T any](registry *Registry, configs ...Config) *Model[T] {
+	key := newRegistryKey[T](configs)
+	if existing, ok := registry.load(key); ok {
+		return existing.(*Model[T])
+	}
+
+	model := newModel[T](configs...)
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	if existing, ok := registry.models[key]; ok {
+		return existing.(*Model[T])
+	}
+	registry.models[key] = model
+	return model
+}
@@ -0,0 +1,74 @@
+package rem
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	type testModel struct {
+		Id   int64  `db:"id" db_primary:"true"`
+		Name string `db:"name"`
+	}
+	registry := NewRegistry()
+
+	m1 := UseIn[testModel](registry)
+	m2 := UseIn[testModel](registry)
+	if m1 != m2 {
+		t.Errorf("Expected '%#v', got '%#v'", m1, m2)
+	}
+	if m3 := Use[testModel](); m1 == m3 {
+		t.Errorf("Expected '%#v' to be different from '%#v'", m1, m3)
+	}
+	defaultRegistry.Reset()
+
+	m4 := RegisterIn[testModel](registry)
+	if m1 == m4 {
+		t.Errorf("Expected '%#v' to be different from '%#v'", m1, m4)
+	}
+	if m5 := UseIn[testModel](registry); m4 != m5 {
+		t.Errorf("Expected '%#v', got '%#v'", m4, m5)
+	}
+
+	registry.Reset()
+	if m6 := UseIn[testModel](registry); m4 == m6 {
+		t.Errorf("Expected '%#v' to be different from '%#v'", m4, m6)
+	}
+
+	// Models declared in different scopes with the same name must not collide.
+	func() {
+		type testModel struct {
+			Id int64 `db:"id" db_primary:"true"`
+		}
+		if m := UseIn[testModel](registry); len(m.Fields) != 1 {
+			t.Errorf("Expected one field, got '%#v'", m.Fields)
+		}
+	}()
+}
+
+func TestRegistryConcurrent(t *testing.T) {
+	type testModel struct {
+		Id int64 `db:"id" db_primary:"true"`
+	}
+	registry := NewRegistry()
+
+	var wg sync.WaitGroup
+	models := make([]*Model[testModel], 10)
+	for i := range models {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				RegisterIn[testModel](registry, Config{Table: "other"})
+			}
+			models[i] = UseIn[testModel](registry)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, model := range models[1:] {
+		if model != models[0] {
+			t.Errorf("Expected '%#v', got '%#v'", models[0], model)
+		}
+	}
+}
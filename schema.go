@@ -0,0 +1,227 @@
+package rem
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type ColumnDiff struct {
+	ActualNullable   bool
+	ActualType       string
+	Column           string
+	ExpectedNullable bool
+	ExpectedType     string
+}
+
+type ColumnSchema struct {
+	AutoIncrement bool
+	Default       sql.NullString
+	Name          string
+	Nullable      bool
+	Type          string
+}
+
+var ErrTableNotExist = errors.New("rem: table does not exist")
+
+type ForeignKeySchema struct {
+	Columns           []string
+	Name              string
+	OnDelete          string
+	OnUpdate          string
+	ReferencedColumns []string
+	ReferencedTable   string
+}
+
+type Introspector interface {
+	IntrospectTable(ctx context.Context, db Executor, table string) (*TableSchema, error)
+}
+
+type SchemaChecker interface {
+	CheckSchema(db Executor) (*TableDiff, error)
+}
+
+type SchemaDiff struct {
+	Tables []*TableDiff
+}
+
+func (diff *SchemaDiff) Empty() bool {
+	return len(diff.Tables) == 0
+}
+
+func (diff *SchemaDiff) String() string {
+	tables := make([]string, len(diff.Tables))
+	for i, table := range diff.Tables {
+		tables[i] = table.String()
+	}
+	return strings.Join(tables, "\n")
+}
+
+type SchemaSnapshot struct {
+	Tables []*TableSchema
+}
+
+func (snapshot *SchemaSnapshot) Table(name string) (*TableSchema, bool) {
+	for _, table := range snapshot.Tables {
+		if table.Name == name {
+			return table, true
+		}
+	}
+	return nil, false
+}
+
+type TableDiff struct {
+	ColumnMismatches   []ColumnDiff
+	ExtraColumns       []string
+	Missing            bool
+	MissingColumns     []string
+	MissingForeignKeys []ForeignKeySchema
+	MissingIndexes     []Index
+	Table              string
+}
+
+func (diff *TableDiff) Empty() bool {
+	return !diff.Missing &&
+		len(diff.ColumnMismatches) == 0 &&
+		len(diff.ExtraColumns) == 0 &&
+		len(diff.MissingColumns) == 0 &&
+		len(diff.MissingForeignKeys) == 0 &&
+		len(diff.MissingIndexes) == 0
+}
+
+func (diff *TableDiff) String() string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "table '%s':", diff.Table)
+	if diff.Missing {
+		out.WriteString("\n\tmissing table")
+	}
+	for _, column := range diff.MissingColumns {
+		fmt.Fprintf(&out, "\n\tmissing column '%s'", column)
+	}
+	for _, column := range diff.ExtraColumns {
+		fmt.Fprintf(&out, "\n\textra column '%s'", column)
+	}
+	for _, mismatch := range diff.ColumnMismatches {
+		fmt.Fprintf(&out, "\n\tcolumn '%s' expected %s, got %s", mismatch.Column, columnDescription(mismatch.ExpectedType, mismatch.ExpectedNullable), columnDescription(mismatch.ActualType, mismatch.ActualNullable))
+	}
+	for _, foreignKey := range diff.MissingForeignKeys {
+		fmt.Fprintf(&out, "\n\tmissing foreign key (%s) references '%s' (%s)", strings.Join(foreignKey.Columns, ", "), foreignKey.ReferencedTable, strings.Join(foreignKey.ReferencedColumns, ", "))
+	}
+	for _, index := range diff.MissingIndexes {
+		fmt.Fprintf(&out, "\n\tmissing index '%s' (%s)", index.Name, strings.Join(index.Columns, ", "))
+	}
+	return out.String()
+}
+
+type TableSchema struct {
+	Columns     []ColumnSchema
+	ForeignKeys []ForeignKeySchema
+	Indexes     []Index
+	Name        string
+	PrimaryKey  []string
+}
+
+func (schema *TableSchema) Column(name string) (ColumnSchema, bool) {
+	for _, column := range schema.Columns {
+		if column.Name == name {
+			return column, true
+		}
+	}
+	return ColumnSchema{}, false
+}
+
+func CheckSchema(db Executor, models ...SchemaChecker) (*SchemaDiff, error) {
+	diff := &SchemaDiff{
+		Tables: make([]*TableDiff, 0),
+	}
+	for _, model := range models {
+		tableDiff, err := model.CheckSchema(db)
+		if err != nil {
+			return nil, err
+		}
+		if !tableDiff.Empty() {
+			diff.Tables = append(diff.Tables, tableDiff)
+		}
+	}
+	return diff, nil
+}
+
+func Snapshot(models ...MigrationModel) (*SchemaSnapshot, error) {
+	snapshot := &SchemaSnapshot{
+		Tables: make([]*TableSchema, 0, len(models)),
+	}
+	for _, model := range models {
+		table, err := model.TableSchema()
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Tables = append(snapshot.Tables, table)
+	}
+	return snapshot, nil
+}
+
+func columnDescription(columnType string, nullable bool) string {
+	if nullable {
+		return columnType + " NULL"
+	}
+	return columnType + " NOT NULL"
+}
+
+func foreignKeyEqual(a ForeignKeySchema, b ForeignKeySchema) bool {
+	if a.ReferencedTable != b.ReferencedTable || len(a.Columns) != len(b.Columns) {
+		return false
+	}
+	// Column pairs may be listed in any order.
+	for i := range a.Columns {
+		found := false
+		for j := range b.Columns {
+			if a.Columns[i] == b.Columns[j] && a.ReferencedColumns[i] == b.ReferencedColumns[j] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeColumnType(columnType string) string {
+	columnType = strings.Join(strings.Fields(strings.ToUpper(columnType)), " ")
+	switch columnType {
+	case "BIGSERIAL":
+		return "BIGINT"
+	case "SERIAL":
+		return "INTEGER"
+	case "SMALLSERIAL":
+		return "SMALLINT"
+	case "INT":
+		return "INTEGER"
+	case "BOOLEAN", "BOOL":
+		return "BOOLEAN"
+	}
+
+	// Integer display widths, such as BIGINT(20), don't affect storage.
+	if base, _, ok := strings.Cut(columnType, "("); ok && strings.HasSuffix(base, "INT") {
+		if base == "TINYINT" && columnType == "TINYINT(1)" {
+			return "BOOLEAN"
+		}
+		return normalizeColumnType(base)
+	}
+	return columnType
+}
+
+func parseColumnType(definition string) (string, bool) {
+	upper := strings.ToUpper(definition)
+	end := len(definition)
+	for _, keyword := range []string{" PRIMARY KEY", " NOT NULL", " NULL", " REFERENCES ", " DEFAULT ", " UNIQUE", " AUTO_INCREMENT", " AUTOINCREMENT"} {
+		if i := strings.Index(upper, keyword); i >= 0 && i < end {
+			end = i
+		}
+	}
+	nullable := !strings.Contains(upper, " NOT NULL") && !strings.Contains(upper, " PRIMARY KEY")
+	return strings.TrimSpace(definition[:end]), nullable
+}
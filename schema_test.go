@@ -0,0 +1,165 @@
+package rem
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type testIntrospectorDialect struct {
+	testDialect
+	schemas map[string]*TableSchema
+}
+
+func (dialect testIntrospectorDialect) ColumnType(field reflect.StructField) (string, error) {
+	return field.Tag.Get("db_type"), nil
+}
+
+func (dialect testIntrospectorDialect) IntrospectTable(ctx context.Context, db Executor, table string) (*TableSchema, error) {
+	if schema, ok := dialect.schemas[table]; ok {
+		return schema, nil
+	}
+	return nil, ErrTableNotExist
+}
+
+type testGroupsCheckSchema struct {
+	Id int64 `db:"id" db_primary:"true" db_type:"BIGSERIAL PRIMARY KEY NOT NULL"`
+}
+
+type testAccountsCheckSchema struct {
+	Email string                            `db:"email" db_type:"VARCHAR(100) NULL" db_index:"true"`
+	Group ForeignKey[testGroupsCheckSchema] `db:"group_id" db_type:"BIGINT NOT NULL REFERENCES groups (id)"`
+	Id    int64                             `db:"id" db_primary:"true" db_type:"BIGSERIAL PRIMARY KEY NOT NULL"`
+	Name  string                            `db:"name" db_type:"VARCHAR(100) NOT NULL"`
+}
+
+func TestCheckSchema(t *testing.T) {
+	defer func() {
+		defaultDialect = nil
+	}()
+	SetDialect(testIntrospectorDialect{
+		schemas: map[string]*TableSchema{
+			"testaccountscheckschema": {
+				Columns: []ColumnSchema{
+					{AutoIncrement: true, Name: "id", Type: "bigint"},
+					{Name: "email", Type: "VARCHAR(50)"},
+					{Name: "group_id", Type: "BIGINT(20)"},
+					{Name: "legacy", Nullable: true, Type: "TEXT"},
+				},
+				Name:       "testaccountscheckschema",
+				PrimaryKey: []string{"id"},
+			},
+		},
+	})
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	diff, err := CheckSchema(db, Use[testAccountsCheckSchema](), Use[testGroupsCheckSchema]())
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	expected := &SchemaDiff{
+		Tables: []*TableDiff{
+			{
+				ColumnMismatches: []ColumnDiff{
+					{ActualType: "VARCHAR(50)", Column: "email", ExpectedNullable: true, ExpectedType: "VARCHAR(100)"},
+				},
+				ExtraColumns:   []string{"legacy"},
+				MissingColumns: []string{"name"},
+				MissingForeignKeys: []ForeignKeySchema{
+					{Columns: []string{"group_id"}, ReferencedColumns: []string{"id"}, ReferencedTable: "testgroupscheckschema"},
+				},
+				MissingIndexes: []Index{
+					{Columns: []string{"email"}, Name: "testaccountscheckschema_email_idx"},
+				},
+				Table: "testaccountscheckschema",
+			},
+			{
+				ColumnMismatches:   []ColumnDiff{},
+				ExtraColumns:       []string{},
+				Missing:            true,
+				MissingColumns:     []string{},
+				MissingForeignKeys: []ForeignKeySchema{},
+				MissingIndexes:     []Index{},
+				Table:              "testgroupscheckschema",
+			},
+		},
+	}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("Expected '%+v', got '%+v'", expected, diff)
+	}
+
+	expectedString := `table 'testaccountscheckschema':
+	missing column 'name'
+	extra column 'legacy'
+	column 'email' expected VARCHAR(100) NULL, got VARCHAR(50) NOT NULL
+	missing foreign key (group_id) references 'testgroupscheckschema' (id)
+	missing index 'testaccountscheckschema_email_idx' (email)
+table 'testgroupscheckschema':
+	missing table`
+	if diff.String() != expectedString {
+		t.Errorf("Expected '%s', got '%s'", expectedString, diff.String())
+	}
+
+	// No differences.
+	SetDialect(testIntrospectorDialect{
+		schemas: map[string]*TableSchema{
+			"testgroupscheckschema": {
+				Columns: []ColumnSchema{{AutoIncrement: true, Name: "id", Type: "BIGINT"}},
+				Name:    "testgroupscheckschema",
+			},
+		},
+	})
+	diff, err = CheckSchema(db, Use[testGroupsCheckSchema]())
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if !diff.Empty() {
+		t.Errorf("Expected no differences, got '%s'", diff)
+	}
+}
+
+func TestTableIntrospect(t *testing.T) {
+	type testAccounts struct {
+		Id int64 `db:"id" db_primary:"true"`
+	}
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	dialect := testIntrospectorDialect{
+		schemas: map[string]*TableSchema{
+			"testaccounts": {
+				Columns: []ColumnSchema{{Name: "id", Type: "INTEGER"}},
+				Name:    "testaccounts",
+			},
+		},
+	}
+	schema, err := Use[testAccounts]().Dialect(dialect).TableIntrospect(db)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if schema.Name != "testaccounts" {
+		t.Errorf("Expected table 'testaccounts', got '%s'", schema.Name)
+	}
+	if column, ok := schema.Column("id"); !ok || column.Type != "INTEGER" {
+		t.Errorf("Expected column 'id', got '%+v'", column)
+	}
+	if _, ok := schema.Column("bogus"); ok {
+		t.Error("Expected column 'bogus' to not exist")
+	}
+
+	_, err = Use[testAccounts]().Dialect(testDialect{}).TableIntrospect(db)
+	if err == nil || err.Error() != "rem: dialect 'rem.testDialect' does not support introspection" {
+		t.Errorf("Expected unsupported dialect error, got '%v'", err)
+	}
+}
@@ -1,7 +1,9 @@
 package sqlitedialect
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"reflect"
 	"sort"
@@ -13,7 +15,84 @@ import (
 )
 
 type SqliteDialect struct {
-	PreserveBooleans bool
+	MaxVariableNumber int
+	PreserveBooleans  bool
+	Returning         bool
+}
+
+var (
+	migrationLockExpiry   = time.Minute
+	migrationLockInterval = 100 * time.Millisecond
+)
+
+func (dialect SqliteDialect) AlterTableColumn(ctx context.Context, db rem.Executor, config rem.QueryConfig, column string) (sql.Result, error) {
+	if _, ok := config.Fields[column]; !ok {
+		return nil, fmt.Errorf("rem: invalid column '%s' on model for table '%s'", column, config.Table)
+	}
+
+	// SQLite can't alter columns, so the table is rebuilt from the model and the rows are copied.
+	schema, table := "", config.Table
+	if i := strings.LastIndex(config.Table, "."); i > -1 {
+		schema, table = config.Table[:i+1], config.Table[i+1:]
+	}
+	liveColumns, _, err := dialect.introspectColumns(ctx, db, table)
+	if err != nil {
+		return nil, err
+	}
+	if len(liveColumns) == 0 {
+		return nil, fmt.Errorf("%w: %s", rem.ErrTableNotExist, config.Table)
+	}
+	columns := make([]string, 0, len(liveColumns))
+	for _, liveColumn := range liveColumns {
+		if _, ok := config.Fields[liveColumn.Name]; !ok {
+			return nil, fmt.Errorf("rem: column '%s' of table '%s' is not on the model and would be lost by rebuilding the table", liveColumn.Name, config.Table)
+		}
+		columns = append(columns, dialect.QuoteIdentifier(liveColumn.Name))
+	}
+	sort.Strings(columns)
+
+	rebuildConfig := config
+	rebuildConfig.Table = schema + "rem_alter_" + table
+	create, err := dialect.BuildTableCreate(rebuildConfig, rem.TableCreateConfig{})
+	if err != nil {
+		return nil, err
+	}
+	queryStrings := []string{
+		create,
+		fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", dialect.QuoteIdentifier(rebuildConfig.Table), strings.Join(columns, ", "), strings.Join(columns, ", "), dialect.QuoteIdentifier(config.Table)),
+		fmt.Sprintf("DROP TABLE %s", dialect.QuoteIdentifier(config.Table)),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", dialect.QuoteIdentifier(rebuildConfig.Table), dialect.QuoteIdentifier(table)),
+	}
+	for _, index := range config.Indexes {
+		queryString, err := dialect.BuildTableIndexCreate(config, index.Name, rem.TableIndexCreateConfig{})
+		if err != nil {
+			return nil, err
+		}
+		queryStrings = append(queryStrings, queryString)
+	}
+
+	switch db := db.(type) {
+	case *sql.DB:
+		// Foreign key enforcement is per connection, so the rebuild runs on a single one.
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		return dialect.rebuildTable(ctx, conn, queryStrings)
+	case *sql.Conn:
+		return dialect.rebuildTable(ctx, db, queryStrings)
+	}
+
+	// Foreign key enforcement can't be changed within a transaction.
+	foreignKeys, err := dialect.foreignKeysEnabled(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	if foreignKeys {
+		return nil, errors.New("rem: SQLite can't rebuild a table within a transaction while foreign keys are enabled. Run PRAGMA foreign_keys = OFF before beginning the transaction")
+	}
+	return dialect.rebuildTableStatements(ctx, db, queryStrings)
 }
 
 func (dialect SqliteDialect) BuildDelete(config rem.QueryConfig) (string, []interface{}, error) {
@@ -64,42 +143,72 @@ func (dialect SqliteDialect) BuildDelete(config rem.QueryConfig) (string, []inte
 }
 
 func (dialect SqliteDialect) BuildInsert(config rem.QueryConfig, rowMap map[string]interface{}, columns ...string) (string, []interface{}, error) {
+	return dialect.BuildInsertMany(config, []map[string]interface{}{rowMap}, columns...)
+}
+
+func (dialect SqliteDialect) BuildInsertMany(config rem.QueryConfig, rowMaps []map[string]interface{}, columns ...string) (string, []interface{}, error) {
 	args := make([]interface{}, 0)
 	var queryString strings.Builder
 
+	if len(rowMaps) == 0 {
+		return "", nil, fmt.Errorf("rem: no rows specified for INSERT")
+	}
+
 	queryString.WriteString("INSERT INTO ")
 	queryString.WriteString(dialect.QuoteIdentifier(config.Table))
 	queryString.WriteString(" (")
-	first := true
-	for _, column := range columns {
-		if arg, ok := rowMap[column]; ok {
-			if _, ok := config.Fields[column]; !ok {
-				return "", nil, fmt.Errorf("rem: field for column '%s' not found on model for table '%s'", column, config.Table)
+	for i, column := range columns {
+		if _, ok := config.Fields[column]; !ok {
+			return "", nil, fmt.Errorf("rem: field for column '%s' not found on model for table '%s'", column, config.Table)
+		}
+		if i > 0 {
+			queryString.WriteString(",")
+		}
+		queryString.WriteString(dialect.QuoteIdentifier(column))
+	}
+
+	queryString.WriteString(") VALUES ")
+	for i, rowMap := range rowMaps {
+		if i > 0 {
+			queryString.WriteString(",")
+		}
+		queryString.WriteString("(")
+		for j, column := range columns {
+			arg, ok := rowMap[column]
+			if !ok {
+				return "", nil, fmt.Errorf("rem: invalid column '%s' on INSERT", column)
 			}
 			args = append(args, arg)
-			if first {
-				first = false
-			} else {
+			if j > 0 {
 				queryString.WriteString(",")
 			}
-			queryString.WriteString(dialect.QuoteIdentifier(column))
-		} else {
-			return "", nil, fmt.Errorf("rem: invalid column '%s' on INSERT", column)
+			queryString.WriteString(dialect.Param(len(args)))
 		}
+		queryString.WriteString(")")
 	}
 
-	queryString.WriteString(") VALUES (")
-	for i := 1; i <= len(rowMap); i++ {
-		if i > 1 {
-			queryString.WriteString(",")
-		}
-		queryString.WriteString(dialect.Param(i))
-	}
-	queryString.WriteString(")")
+	// RETURNING
+	queryString.WriteString(dialect.buildReturning(config))
 
 	return queryString.String(), args, nil
 }
 
+func (dialect SqliteDialect) buildHaving(config rem.QueryConfig, args []interface{}) (string, []interface{}, error) {
+	var queryPart strings.Builder
+	if len(config.Having) > 0 {
+		queryPart.WriteString(" HAVING")
+		for _, where := range config.Having {
+			queryWhere, whereArgs, err := where.StringWithArgs(dialect, args)
+			if err != nil {
+				return "", nil, err
+			}
+			args = whereArgs
+			queryPart.WriteString(queryWhere)
+		}
+	}
+	return queryPart.String(), args, nil
+}
+
 func (dialect SqliteDialect) buildJoins(config rem.QueryConfig, args []interface{}) (string, []interface{}, error) {
 	var queryPart strings.Builder
 	if len(config.Joins) > 0 {
@@ -120,7 +229,25 @@ func (dialect SqliteDialect) buildJoins(config rem.QueryConfig, args []interface
 	return queryPart.String(), args, nil
 }
 
+func (dialect SqliteDialect) buildReturning(config rem.QueryConfig) string {
+	var queryPart strings.Builder
+	if len(config.Returning) > 0 {
+		queryPart.WriteString(" RETURNING ")
+		for i, column := range config.Returning {
+			if i > 0 {
+				queryPart.WriteString(",")
+			}
+			queryPart.WriteString(dialect.QuoteIdentifier(column))
+		}
+	}
+	return queryPart.String()
+}
+
 func (dialect SqliteDialect) BuildSelect(config rem.QueryConfig) (string, []interface{}, error) {
+	if config.ForShare || config.ForUpdate || config.NoWait || config.SkipLocked {
+		return "", nil, fmt.Errorf("rem: SELECT does not support row locking")
+	}
+
 	args := append([]interface{}(nil), config.Params...)
 	var queryString strings.Builder
 	if config.Count {
@@ -169,6 +296,38 @@ func (dialect SqliteDialect) BuildSelect(config rem.QueryConfig) (string, []inte
 		queryString.WriteString(where)
 	}
 
+	// GROUP BY
+	if len(config.GroupBy) > 0 {
+		queryString.WriteString(" GROUP BY ")
+		for i, column := range config.GroupBy {
+			if i > 0 {
+				queryString.WriteString(",")
+			}
+			switch cv := column.(type) {
+			case string:
+				queryString.WriteString(dialect.QuoteIdentifier(cv))
+
+			case rem.DialectStringer:
+				queryString.WriteString(cv.StringForDialect(dialect))
+
+			case fmt.Stringer:
+				queryString.WriteString(cv.String())
+
+			default:
+				return "", nil, fmt.Errorf("rem: invalid column type %#v", column)
+			}
+		}
+	}
+
+	// HAVING
+	having, args, err := dialect.buildHaving(config, args)
+	if err != nil {
+		return "", nil, err
+	}
+	if having != "" {
+		queryString.WriteString(having)
+	}
+
 	// ORDER BY
 	if len(config.Sort) > 0 {
 		queryString.WriteString(" ORDER BY ")
@@ -209,17 +368,25 @@ func (dialect SqliteDialect) BuildTableColumnAdd(config rem.QueryConfig, column
 		return "", fmt.Errorf("rem: invalid column '%s' on model for table '%s'", column, config.Table)
 	}
 
-	columnType, err := dialect.ColumnType(field)
+	columnType, err := dialect.columnType(field, len(config.PrimaryColumns) > 1)
 	if err != nil {
 		return "", err
 	}
 	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", dialect.QuoteIdentifier(config.Table), dialect.QuoteIdentifier(column), columnType), nil
 }
 
+func (dialect SqliteDialect) BuildTableColumnAlter(config rem.QueryConfig, column string) ([]string, error) {
+	return nil, errors.New("rem: SQLite alters columns by rebuilding the table, which must be executed on a connection. Use TableColumnAlter instead")
+}
+
 func (dialect SqliteDialect) BuildTableColumnDrop(config rem.QueryConfig, column string) (string, error) {
 	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", dialect.QuoteIdentifier(config.Table), dialect.QuoteIdentifier(column)), nil
 }
 
+func (dialect SqliteDialect) BuildTableColumnRename(config rem.QueryConfig, from string, to string) (string, error) {
+	return fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s", dialect.QuoteIdentifier(config.Table), dialect.QuoteIdentifier(from), dialect.QuoteIdentifier(to)), nil
+}
+
 func (dialect SqliteDialect) BuildTableCreate(config rem.QueryConfig, tableCreateConfig rem.TableCreateConfig) (string, error) {
 	var sql strings.Builder
 	sql.WriteString("CREATE TABLE ")
@@ -232,7 +399,8 @@ func (dialect SqliteDialect) BuildTableCreate(config rem.QueryConfig, tableCreat
 	sort.Strings(fieldNames)
 	for i, fieldName := range fieldNames {
 		field := config.Fields[fieldName]
-		columnType, err := dialect.ColumnType(field)
+		// Composite primary keys are declared as a table constraint.
+		columnType, err := dialect.columnType(field, len(config.PrimaryColumns) > 1)
 		if err != nil {
 			return "", err
 		}
@@ -244,11 +412,74 @@ func (dialect SqliteDialect) BuildTableCreate(config rem.QueryConfig, tableCreat
 		sql.WriteString(" ")
 		sql.WriteString(columnType)
 	}
+	constraints, err := dialect.buildTableConstraints(config)
+	if err != nil {
+		return "", err
+	}
+	sql.WriteString(constraints)
 	sql.WriteString("\n)")
 
 	return sql.String(), nil
 }
 
+func (dialect SqliteDialect) buildTableConstraints(config rem.QueryConfig) (string, error) {
+	var sql strings.Builder
+	if len(config.PrimaryColumns) > 1 {
+		sql.WriteString(",\n\tPRIMARY KEY (")
+		for i, column := range config.PrimaryColumns {
+			if i > 0 {
+				sql.WriteString(", ")
+			}
+			sql.WriteString(dialect.QuoteIdentifier(column))
+		}
+		sql.WriteString(")")
+	}
+
+	// Composite foreign keys are grouped by struct field.
+	foreignKeys := make(map[string]map[string]string)
+	foreignKeyFields := make(map[string]reflect.StructField)
+	for column, field := range config.Fields {
+		if references, ok := field.Tag.Lookup("db_references"); ok {
+			if _, ok := foreignKeys[field.Name]; !ok {
+				foreignKeys[field.Name] = make(map[string]string)
+				foreignKeyFields[field.Name] = field
+			}
+			foreignKeys[field.Name][references] = column
+		}
+	}
+	fieldNames := maps.Keys(foreignKeys)
+	sort.Strings(fieldNames)
+	for _, fieldName := range fieldNames {
+		field := foreignKeyFields[fieldName]
+		subModelQ := reflect.New(field.Type).MethodByName("Model").Call(nil)
+		subPrimaryColumns := reflect.Indirect(subModelQ[0]).FieldByName("PrimaryColumns").Interface().([]string)
+		subTable := reflect.Indirect(subModelQ[0]).FieldByName("Table").Interface().(string)
+
+		columns := make([]string, len(subPrimaryColumns))
+		references := make([]string, len(subPrimaryColumns))
+		for i, subPrimaryColumn := range subPrimaryColumns {
+			column, ok := foreignKeys[fieldName][subPrimaryColumn]
+			if !ok {
+				return "", fmt.Errorf("rem: foreign key field '%s' is missing a column for '%s' on table '%s'", fieldName, subPrimaryColumn, subTable)
+			}
+			columns[i] = dialect.QuoteIdentifier(column)
+			references[i] = dialect.QuoteIdentifier(subPrimaryColumn)
+		}
+		sql.WriteString(fmt.Sprintf(",\n\tFOREIGN KEY (%s) REFERENCES %s (%s)", strings.Join(columns, ", "), dialect.QuoteIdentifier(subTable), strings.Join(references, ", ")))
+
+		if tagOnUpdate := field.Tag.Get("db_on_update"); tagOnUpdate != "" {
+			// ON UPDATE.
+			sql.WriteString(fmt.Sprint(" ON UPDATE ", tagOnUpdate))
+		}
+
+		if tagOnDelete := field.Tag.Get("db_on_delete"); tagOnDelete != "" {
+			// ON DELETE.
+			sql.WriteString(fmt.Sprint(" ON DELETE ", tagOnDelete))
+		}
+	}
+	return sql.String(), nil
+}
+
 func (dialect SqliteDialect) BuildTableDrop(config rem.QueryConfig, tableDropConfig rem.TableDropConfig) (string, error) {
 	var queryString strings.Builder
 	queryString.WriteString("DROP TABLE ")
@@ -259,6 +490,65 @@ func (dialect SqliteDialect) BuildTableDrop(config rem.QueryConfig, tableDropCon
 	return queryString.String(), nil
 }
 
+func (dialect SqliteDialect) BuildTableIndexCreate(config rem.QueryConfig, name string, tableIndexCreateConfig rem.TableIndexCreateConfig) (string, error) {
+	var index *rem.Index
+	for i := range config.Indexes {
+		if config.Indexes[i].Name == name {
+			index = &config.Indexes[i]
+			break
+		}
+	}
+	if index == nil {
+		return "", fmt.Errorf("rem: invalid index '%s' on model for table '%s'", name, config.Table)
+	}
+	if len(index.Columns) == 0 {
+		return "", fmt.Errorf("rem: index '%s' on table '%s' has no columns", name, config.Table)
+	}
+	if index.Method != "" {
+		return "", fmt.Errorf("rem: CREATE INDEX does not support USING")
+	}
+
+	var sql strings.Builder
+	sql.WriteString("CREATE ")
+	if index.Unique {
+		sql.WriteString("UNIQUE ")
+	}
+	sql.WriteString("INDEX ")
+	if tableIndexCreateConfig.IfNotExists {
+		sql.WriteString("IF NOT EXISTS ")
+	}
+	sql.WriteString(dialect.QuoteIdentifier(index.Name))
+	sql.WriteString(" ON ")
+	sql.WriteString(dialect.QuoteIdentifier(config.Table))
+	sql.WriteString(" (")
+	for i, column := range index.Columns {
+		if i > 0 {
+			sql.WriteString(", ")
+		}
+		sql.WriteString(dialect.QuoteIdentifier(column))
+	}
+	sql.WriteString(")")
+	if index.Where != "" {
+		sql.WriteString(" WHERE ")
+		sql.WriteString(index.Where)
+	}
+	return sql.String(), nil
+}
+
+func (dialect SqliteDialect) BuildTableIndexDrop(config rem.QueryConfig, name string, tableIndexDropConfig rem.TableIndexDropConfig) (string, error) {
+	var sql strings.Builder
+	sql.WriteString("DROP INDEX ")
+	if tableIndexDropConfig.IfExists {
+		sql.WriteString("IF EXISTS ")
+	}
+	sql.WriteString(dialect.QuoteIdentifier(name))
+	return sql.String(), nil
+}
+
+func (dialect SqliteDialect) BuildTableRename(config rem.QueryConfig, name string) (string, error) {
+	return fmt.Sprintf("ALTER TABLE %s RENAME TO %s", dialect.QuoteIdentifier(config.Table), dialect.QuoteIdentifier(name)), nil
+}
+
 func (dialect SqliteDialect) BuildUpdate(config rem.QueryConfig, rowMap map[string]interface{}, columns ...string) (string, []interface{}, error) {
 	args := append([]interface{}(nil), config.Params...)
 	var queryString strings.Builder
@@ -293,6 +583,9 @@ func (dialect SqliteDialect) BuildUpdate(config rem.QueryConfig, rowMap map[stri
 		queryString.WriteString(where)
 	}
 
+	// RETURNING
+	queryString.WriteString(dialect.buildReturning(config))
+
 	// ORDER BY
 	if len(config.Sort) > 0 {
 		queryString.WriteString(" ORDER BY ")
@@ -325,6 +618,57 @@ func (dialect SqliteDialect) BuildUpdate(config rem.QueryConfig, rowMap map[stri
 	return queryString.String(), args, nil
 }
 
+func (dialect SqliteDialect) BuildUpsert(config rem.QueryConfig, rowMap map[string]interface{}, upsertConfig rem.UpsertConfig, columns ...string) (string, []interface{}, error) {
+	insertConfig := config
+	insertConfig.Returning = nil
+	queryString, args, err := dialect.BuildInsert(insertConfig, rowMap, columns...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var sql strings.Builder
+	sql.WriteString(queryString)
+	sql.WriteString(" ON CONFLICT")
+	if len(upsertConfig.ConflictColumns) > 0 {
+		sql.WriteString(" (")
+		for i, column := range upsertConfig.ConflictColumns {
+			if i > 0 {
+				sql.WriteString(",")
+			}
+			sql.WriteString(dialect.QuoteIdentifier(column))
+		}
+		sql.WriteString(")")
+	}
+
+	if upsertConfig.DoNothing {
+		sql.WriteString(" DO NOTHING")
+	} else {
+		if len(upsertConfig.ConflictColumns) == 0 {
+			return "", nil, fmt.Errorf("rem: no conflict columns specified for ON CONFLICT DO UPDATE")
+		}
+		if len(upsertConfig.UpdateColumns) == 0 {
+			return "", nil, fmt.Errorf("rem: no columns specified for ON CONFLICT DO UPDATE")
+		}
+		sql.WriteString(" DO UPDATE SET ")
+		for i, column := range upsertConfig.UpdateColumns {
+			if _, ok := config.Fields[column]; !ok {
+				return "", nil, fmt.Errorf("rem: invalid column '%s' on ON CONFLICT DO UPDATE", column)
+			}
+			if i > 0 {
+				sql.WriteString(",")
+			}
+			sql.WriteString(dialect.QuoteIdentifier(column))
+			sql.WriteString(" = EXCLUDED.")
+			sql.WriteString(dialect.QuoteIdentifier(column))
+		}
+	}
+
+	// RETURNING
+	sql.WriteString(dialect.buildReturning(config))
+
+	return sql.String(), args, nil
+}
+
 func (dialect SqliteDialect) buildWhere(config rem.QueryConfig, args []interface{}) (string, []interface{}, error) {
 	var queryPart strings.Builder
 	if len(config.Filters) > 0 {
@@ -342,6 +686,10 @@ func (dialect SqliteDialect) buildWhere(config rem.QueryConfig, args []interface
 }
 
 func (dialect SqliteDialect) ColumnType(field reflect.StructField) (string, error) {
+	return dialect.columnType(field, false)
+}
+
+func (dialect SqliteDialect) columnType(field reflect.StructField, compositePrimary bool) (string, error) {
 	tagType := field.Tag.Get("db_type")
 	if tagType != "" {
 		return tagType, nil
@@ -352,7 +700,7 @@ func (dialect SqliteDialect) ColumnType(field reflect.StructField) (string, erro
 	var columnPrimary string
 	var columnType string
 
-	if field.Tag.Get("db_primary") == "true" {
+	if field.Tag.Get("db_primary") == "true" && !compositePrimary {
 		columnPrimary = " PRIMARY KEY"
 	}
 
@@ -404,8 +752,11 @@ func (dialect SqliteDialect) ColumnType(field reflect.StructField) (string, erro
 			subModelQ := fv.Addr().MethodByName("Model").Call(nil)
 			subFields := reflect.Indirect(subModelQ[0]).FieldByName("Fields").Interface().(map[string]reflect.StructField)
 			subPrimaryColumn := reflect.Indirect(subModelQ[0]).FieldByName("PrimaryColumn").Interface().(string)
+			if references, ok := field.Tag.Lookup("db_references"); ok {
+				subPrimaryColumn = references
+			}
 			subTable := reflect.Indirect(subModelQ[0]).FieldByName("Table").Interface().(string)
-			columnTypeTemp, err := dialect.ColumnType(subFields[subPrimaryColumn])
+			columnTypeTemp, err := dialect.columnType(subFields[subPrimaryColumn], false)
 			if err != nil {
 				return "", err
 			}
@@ -415,16 +766,19 @@ func (dialect SqliteDialect) ColumnType(field reflect.StructField) (string, erro
 			if strings.HasPrefix(field.Type.String(), "rem.NullForeignKey[") {
 				columnNull = " NULL"
 			}
-			columnNull = fmt.Sprintf("%s REFERENCES %s (%s)", columnNull, dialect.QuoteIdentifier(subTable), dialect.QuoteIdentifier(subPrimaryColumn))
+			// Composite foreign keys are declared as a table constraint.
+			if _, ok := field.Tag.Lookup("db_references"); !ok {
+				columnNull = fmt.Sprintf("%s REFERENCES %s (%s)", columnNull, dialect.QuoteIdentifier(subTable), dialect.QuoteIdentifier(subPrimaryColumn))
 
-			if tagOnUpdate := field.Tag.Get("db_on_update"); tagOnUpdate != "" {
-				// ON UPDATE.
-				columnNull = fmt.Sprint(columnNull, " ON UPDATE ", tagOnUpdate)
-			}
+				if tagOnUpdate := field.Tag.Get("db_on_update"); tagOnUpdate != "" {
+					// ON UPDATE.
+					columnNull = fmt.Sprint(columnNull, " ON UPDATE ", tagOnUpdate)
+				}
 
-			if tagOnDelete := field.Tag.Get("db_on_delete"); tagOnDelete != "" {
-				// ON DELETE.
-				columnNull = fmt.Sprint(columnNull, " ON DELETE ", tagOnDelete)
+				if tagOnDelete := field.Tag.Get("db_on_delete"); tagOnDelete != "" {
+					// ON DELETE.
+					columnNull = fmt.Sprint(columnNull, " ON DELETE ", tagOnDelete)
+				}
 			}
 		}
 	}
@@ -446,10 +800,312 @@ func (dialect SqliteDialect) ColumnType(field reflect.StructField) (string, erro
 	return fmt.Sprint(columnType, columnPrimary, columnNull), nil
 }
 
+func (dialect SqliteDialect) CompositePrimaryColumnType(field reflect.StructField) (string, error) {
+	return dialect.columnType(field, true)
+}
+
+func (dialect SqliteDialect) foreignKeysEnabled(ctx context.Context, db rem.Executor) (bool, error) {
+	var enabled bool
+	if err := db.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&enabled); err != nil {
+		return false, err
+	}
+	return enabled, nil
+}
+
+func (dialect SqliteDialect) introspectColumns(ctx context.Context, db rem.Executor, table string) ([]rem.ColumnSchema, []string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT name, type, "notnull", dflt_value, pk FROM pragma_table_info(?) ORDER BY cid`, table)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	columns := make([]rem.ColumnSchema, 0)
+	primaryKeys := make(map[int]string)
+	for rows.Next() {
+		var column rem.ColumnSchema
+		var notNull bool
+		var pk int
+		if err := rows.Scan(&column.Name, &column.Type, &notNull, &column.Default, &pk); err != nil {
+			return nil, nil, err
+		}
+		column.Type = strings.ToUpper(column.Type)
+		column.Nullable = !notNull
+		if pk > 0 {
+			primaryKeys[pk] = column.Name
+		}
+		columns = append(columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	primaryKey := make([]string, len(primaryKeys))
+	for i := range primaryKey {
+		primaryKey[i] = primaryKeys[i+1]
+	}
+	if len(primaryKey) == 1 {
+		// A single INTEGER PRIMARY KEY is an alias for the rowid.
+		for i := range columns {
+			if columns[i].Name == primaryKey[0] && columns[i].Type == "INTEGER" {
+				columns[i].AutoIncrement = true
+			}
+		}
+	}
+	return columns, primaryKey, nil
+}
+
+func (dialect SqliteDialect) introspectForeignKeys(ctx context.Context, db rem.Executor, table string) ([]rem.ForeignKeySchema, error) {
+	rows, err := db.QueryContext(ctx, `SELECT id, "table", "from", "to", on_update, on_delete FROM pragma_foreign_key_list(?) ORDER BY id, seq`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	foreignKeys := make([]rem.ForeignKeySchema, 0)
+	lastId := -1
+	for rows.Next() {
+		var column, onDelete, onUpdate, referencedTable string
+		var id int
+		var referencedColumn sql.NullString
+		if err := rows.Scan(&id, &referencedTable, &column, &referencedColumn, &onUpdate, &onDelete); err != nil {
+			return nil, err
+		}
+		if id != lastId {
+			lastId = id
+			foreignKeys = append(foreignKeys, rem.ForeignKeySchema{
+				OnDelete:        onDelete,
+				OnUpdate:        onUpdate,
+				ReferencedTable: referencedTable,
+			})
+		}
+		foreignKey := &foreignKeys[len(foreignKeys)-1]
+		foreignKey.Columns = append(foreignKey.Columns, column)
+		foreignKey.ReferencedColumns = append(foreignKey.ReferencedColumns, referencedColumn.String)
+	}
+	return foreignKeys, rows.Err()
+}
+
+func (dialect SqliteDialect) introspectIndexes(ctx context.Context, db rem.Executor, table string) ([]rem.Index, error) {
+	rows, err := db.QueryContext(ctx, `SELECT il.name, il."unique", ii.name, COALESCE(m.sql, '')
+FROM pragma_index_list(?) il
+JOIN pragma_index_info(il.name) ii
+LEFT JOIN sqlite_master m ON m.type = 'index' AND m.name = il.name
+WHERE il.origin <> 'pk'
+ORDER BY il.name, ii.seqno`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	indexes := make([]rem.Index, 0)
+	for rows.Next() {
+		var column, definition, name string
+		var unique bool
+		if err := rows.Scan(&name, &unique, &column, &definition); err != nil {
+			return nil, err
+		}
+		if len(indexes) == 0 || indexes[len(indexes)-1].Name != name {
+			index := rem.Index{
+				Name:   name,
+				Unique: unique,
+			}
+			if i := strings.Index(strings.ToUpper(definition), " WHERE "); i >= 0 {
+				index.Where = strings.TrimSpace(definition[i+len(" WHERE "):])
+			}
+			indexes = append(indexes, index)
+		}
+		index := &indexes[len(indexes)-1]
+		index.Columns = append(index.Columns, column)
+	}
+	return indexes, rows.Err()
+}
+
+func errorField(err error, name string) (reflect.Value, bool) {
+	if err == nil {
+		return reflect.Value{}, false
+	}
+	value := reflect.Indirect(reflect.ValueOf(err))
+	if value.Kind() == reflect.Struct {
+		if field := value.FieldByName(name); field.IsValid() {
+			return field, true
+		}
+	}
+	switch wrapped := err.(type) {
+	case interface{ Unwrap() error }:
+		return errorField(wrapped.Unwrap(), name)
+	case interface{ Unwrap() []error }:
+		for _, err := range wrapped.Unwrap() {
+			if field, ok := errorField(err, name); ok {
+				return field, true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func (dialect SqliteDialect) IntrospectTable(ctx context.Context, db rem.Executor, table string) (*rem.TableSchema, error) {
+	columns, primaryKey, err := dialect.introspectColumns(ctx, db, table)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%w: %s", rem.ErrTableNotExist, table)
+	}
+	foreignKeys, err := dialect.introspectForeignKeys(ctx, db, table)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := dialect.introspectIndexes(ctx, db, table)
+	if err != nil {
+		return nil, err
+	}
+	return &rem.TableSchema{
+		Columns:     columns,
+		ForeignKeys: foreignKeys,
+		Indexes:     indexes,
+		Name:        table,
+		PrimaryKey:  primaryKey,
+	}, nil
+}
+
+func (dialect SqliteDialect) IsRetryableError(err error) bool {
+	// modernc.org/sqlite exposes the result code as a method and mattn/go-sqlite3 as a field.
+	code := -1
+	var coder interface{ Code() int }
+	if errors.As(err, &coder) {
+		code = coder.Code()
+	} else if field, ok := errorField(err, "Code"); ok && field.CanInt() {
+		code = int(field.Int())
+	}
+	// The primary codes of SQLITE_BUSY and SQLITE_LOCKED are 5 and 6.
+	return code >= 0 && (code&0xff == 5 || code&0xff == 6)
+}
+
+func (dialect SqliteDialect) LockMigrations(ctx context.Context, db *sql.DB, timeout time.Duration) (func() error, error) {
+	// SQLite has no session locks, so the lock is a row that only one process can insert.
+	if _, err := db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS `migrationlock` (`id` INTEGER PRIMARY KEY, `locked_at` TEXT NOT NULL)"); err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		_, err := db.ExecContext(ctx, "INSERT INTO `migrationlock` (`id`, `locked_at`) VALUES (1, CURRENT_TIMESTAMP)")
+		if err == nil {
+			break
+		}
+		if !strings.Contains(err.Error(), "UNIQUE constraint failed") && !dialect.IsRetryableError(err) {
+			return nil, err
+		}
+		// Locks are refreshed while held, so an expired lock was left by a process that crashed.
+		result, staleErr := db.ExecContext(ctx, fmt.Sprintf("DELETE FROM `migrationlock` WHERE `id` = 1 AND `locked_at` < datetime('now', '-%d seconds')", max(int64(migrationLockExpiry.Seconds()), 1)))
+		if staleErr == nil {
+			if deleted, _ := result.RowsAffected(); deleted > 0 {
+				continue
+			}
+		}
+		if timeout > 0 && time.Now().After(deadline) {
+			return nil, errors.Join(rem.ErrMigrationLockTimeout, err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(migrationLockInterval):
+		}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(migrationLockExpiry / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				// Failed refreshes are retried on the next tick.
+				db.ExecContext(context.Background(), "UPDATE `migrationlock` SET `locked_at` = CURRENT_TIMESTAMP WHERE `id` = 1")
+			}
+		}
+	}()
+	return func() error {
+		close(done)
+		<-stopped
+		_, err := db.ExecContext(context.Background(), "DELETE FROM `migrationlock` WHERE `id` = 1")
+		return err
+	}, nil
+}
+
 func (dialect SqliteDialect) Param(identifier int) string {
 	return "?"
 }
 
+func (dialect SqliteDialect) ParamLimit() int {
+	if dialect.MaxVariableNumber > 0 {
+		return dialect.MaxVariableNumber
+	}
+	return 999
+}
+
+func (dialect SqliteDialect) rebuildTable(ctx context.Context, conn *sql.Conn, queryStrings []string) (result sql.Result, err error) {
+	// Follows https://www.sqlite.org/lang_altertable.html#otheralter so that dropping the old table doesn't cascade.
+	foreignKeys, err := dialect.foreignKeysEnabled(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if foreignKeys {
+		if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if _, resetErr := conn.ExecContext(context.Background(), "PRAGMA foreign_keys = ON"); resetErr != nil {
+				err = errors.Join(err, resetErr)
+			}
+		}()
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if result, err = dialect.rebuildTableStatements(ctx, tx, queryStrings); err != nil {
+		return nil, errors.Join(err, tx.Rollback())
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (dialect SqliteDialect) rebuildTableStatements(ctx context.Context, db rem.Executor, queryStrings []string) (sql.Result, error) {
+	var result sql.Result
+	var err error
+	for _, queryString := range queryStrings {
+		if result, err = db.ExecContext(ctx, queryString); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := db.QueryContext(ctx, "PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		var table, parent string
+		var rowid sql.NullInt64
+		var fkid int
+		if err := rows.Scan(&table, &rowid, &parent, &fkid); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("rem: foreign key check failed after rebuilding the table. Row %d of table '%s' references a missing row of table '%s'", rowid.Int64, table, parent)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 func (dialect SqliteDialect) QuoteIdentifier(identifier string) string {
 	var query strings.Builder
 	for i, part := range strings.Split(identifier, ".") {
@@ -461,6 +1117,15 @@ func (dialect SqliteDialect) QuoteIdentifier(identifier string) string {
 	return query.String()
 }
 
+func (dialect SqliteDialect) SupportsReturning() bool {
+	// RETURNING requires SQLite 3.35 or later.
+	return dialect.Returning
+}
+
+func (dialect SqliteDialect) SupportsTransactionalDDL() bool {
+	return true
+}
+
 func QuoteIdentifier(identifier string) string {
 	return "`" + strings.Replace(identifier, "`", "``", -1) + "`"
 }
@@ -1,16 +1,133 @@
 package sqlitedialect
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
 	"sort"
 	"testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/evantbyrne/rem"
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 )
 
+func TestAlterTableColumn(t *testing.T) {
+	type testModel struct {
+		Id    int64  `db:"test_id" db_primary:"true"`
+		Label string `db:"test_label" db_default:"''"`
+		Value string `db:"test_value" db_max_length:"100"`
+	}
+
+	dialect := SqliteDialect{}
+	model := rem.Use[testModel]()
+	config := rem.QueryConfig{
+		Fields:  model.Fields,
+		Indexes: []rem.Index{{Columns: []string{"test_value"}, Name: "testmodel_value_idx"}},
+		Table:   "testmodel",
+	}
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	// Columns missing from the live table are left to their defaults.
+	liveColumns := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"name", "type", "notnull", "dflt_value", "pk"}).
+			AddRow("test_id", "INTEGER", true, nil, 1).
+			AddRow("test_value", "TEXT", false, nil, 0)
+	}
+	mock.ExpectQuery(`SELECT name, type, "notnull", dflt_value, pk FROM pragma_table_info(?) ORDER BY cid`).
+		WithArgs("testmodel").
+		WillReturnRows(liveColumns())
+	mock.ExpectQuery("PRAGMA foreign_keys").WillReturnRows(sqlmock.NewRows([]string{"foreign_keys"}).AddRow(1))
+	mock.ExpectExec("PRAGMA foreign_keys = OFF").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TABLE `rem_alter_testmodel` (\n" +
+		"\t`test_id` INTEGER PRIMARY KEY NOT NULL,\n" +
+		"\t`test_label` TEXT NOT NULL DEFAULT '',\n" +
+		"\t`test_value` TEXT NOT NULL\n" +
+		")").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO `rem_alter_testmodel` (`test_id`, `test_value`) SELECT `test_id`, `test_value` FROM `testmodel`").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("DROP TABLE `testmodel`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ALTER TABLE `rem_alter_testmodel` RENAME TO `testmodel`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX `testmodel_value_idx` ON `testmodel` (`test_value`)").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("PRAGMA foreign_key_check").WillReturnRows(sqlmock.NewRows([]string{"table", "rowid", "parent", "fkid"}))
+	mock.ExpectCommit()
+	mock.ExpectExec("PRAGMA foreign_keys = ON").WillReturnResult(sqlmock.NewResult(0, 0))
+	if _, err := dialect.AlterTableColumn(context.Background(), db, config, "test_value"); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// Foreign key violations roll back the rebuild.
+	config.Indexes = nil
+	mock.ExpectQuery(`SELECT name, type, "notnull", dflt_value, pk FROM pragma_table_info(?) ORDER BY cid`).
+		WithArgs("testmodel").
+		WillReturnRows(liveColumns())
+	mock.ExpectQuery("PRAGMA foreign_keys").WillReturnRows(sqlmock.NewRows([]string{"foreign_keys"}).AddRow(0))
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TABLE `rem_alter_testmodel` (\n" +
+		"\t`test_id` INTEGER PRIMARY KEY NOT NULL,\n" +
+		"\t`test_label` TEXT NOT NULL DEFAULT '',\n" +
+		"\t`test_value` TEXT NOT NULL\n" +
+		")").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO `rem_alter_testmodel` (`test_id`, `test_value`) SELECT `test_id`, `test_value` FROM `testmodel`").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("DROP TABLE `testmodel`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ALTER TABLE `rem_alter_testmodel` RENAME TO `testmodel`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("PRAGMA foreign_key_check").
+		WillReturnRows(sqlmock.NewRows([]string{"table", "rowid", "parent", "fkid"}).AddRow("testmodel", 2, "groups", 0))
+	mock.ExpectRollback()
+	_, err = dialect.AlterTableColumn(context.Background(), db, config, "test_value")
+	if err == nil || err.Error() != "rem: foreign key check failed after rebuilding the table. Row 2 of table 'testmodel' references a missing row of table 'groups'" {
+		t.Errorf("Expected foreign key check error, got '%v'", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// Live columns that are not on the model are never dropped.
+	mock.ExpectQuery(`SELECT name, type, "notnull", dflt_value, pk FROM pragma_table_info(?) ORDER BY cid`).
+		WithArgs("testmodel").
+		WillReturnRows(liveColumns().AddRow("test_extra", "TEXT", false, nil, 0))
+	_, err = dialect.AlterTableColumn(context.Background(), db, config, "test_value")
+	if err == nil || err.Error() != "rem: column 'test_extra' of table 'testmodel' is not on the model and would be lost by rebuilding the table" {
+		t.Errorf("Expected extra column error, got '%v'", err)
+	}
+
+	// Foreign keys can't be disabled within a transaction.
+	mock.ExpectBegin()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	mock.ExpectQuery(`SELECT name, type, "notnull", dflt_value, pk FROM pragma_table_info(?) ORDER BY cid`).
+		WithArgs("testmodel").
+		WillReturnRows(liveColumns())
+	mock.ExpectQuery("PRAGMA foreign_keys").WillReturnRows(sqlmock.NewRows([]string{"foreign_keys"}).AddRow(1))
+	_, err = dialect.AlterTableColumn(context.Background(), tx, config, "test_value")
+	if err == nil || err.Error() != "rem: SQLite can't rebuild a table within a transaction while foreign keys are enabled. Run PRAGMA foreign_keys = OFF before beginning the transaction" {
+		t.Errorf("Expected transaction error, got '%v'", err)
+	}
+
+	_, err = dialect.AlterTableColumn(context.Background(), db, config, "missing")
+	if err == nil || err.Error() != "rem: invalid column 'missing' on model for table 'testmodel'" {
+		t.Errorf("Expected invalid column error, got '%v'", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestAs(t *testing.T) {
 	dialect := SqliteDialect{}
 	expected := map[string]rem.SqlAs{
@@ -151,6 +268,61 @@ func TestBuildInsert(t *testing.T) {
 	if !slices.Equal(args, expectedArgs) {
 		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
 	}
+
+	// RETURNING
+	config.Returning = []string{"test_id"}
+	expectedSql = "INSERT INTO `testmodel` (`test_value_1`,`test_value_2`) VALUES (?,?) RETURNING `test_id`"
+	queryString, args, err = dialect.BuildInsert(config, map[string]interface{}{
+		"test_value_1": "foo",
+		"test_value_2": "bar",
+	}, "test_value_1", "test_value_2")
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+	if !slices.Equal(args, expectedArgs) {
+		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
+	}
+}
+
+func TestBuildInsertMany(t *testing.T) {
+	type testModel struct {
+		Id     int64  `db:"test_id" db_primary:"true"`
+		Value1 string `db:"test_value_1" db_max_length:"100"`
+		Value2 string `db:"test_value_2" db_max_length:"100"`
+	}
+
+	dialect := SqliteDialect{}
+	model := rem.Use[testModel]()
+
+	config := model.Query().Config
+	config.Fields = model.Fields
+	config.Table = "testmodel"
+	expectedArgs := []interface{}{"foo", "bar", "baz", "qux"}
+	expectedSql := "INSERT INTO `testmodel` (`test_value_1`,`test_value_2`) VALUES (?,?),(?,?)"
+	queryString, args, err := dialect.BuildInsertMany(config, []map[string]interface{}{
+		{"test_value_1": "foo", "test_value_2": "bar"},
+		{"test_value_1": "baz", "test_value_2": "qux"},
+	}, "test_value_1", "test_value_2")
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+	if !slices.Equal(args, expectedArgs) {
+		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
+	}
+
+	_, _, err = dialect.BuildInsertMany(config, []map[string]interface{}{
+		{"test_value_1": "foo", "test_value_2": "bar"},
+		{"test_value_1": "baz"},
+	}, "test_value_1", "test_value_2")
+	if err == nil {
+		t.Error("Expected error for missing column")
+	}
 }
 
 func TestBuildSelect(t *testing.T) {
@@ -265,6 +437,28 @@ func TestBuildSelect(t *testing.T) {
 		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
 	}
 
+	// GROUP BY and HAVING
+	config = model.Select("group_id", rem.As(rem.Sum("amount"), "total")).
+		Filter("id", ">", 1).
+		GroupBy("group_id").
+		Having(rem.Q(rem.Sum("amount"), ">", 100), rem.Q(rem.Count("*"), ">=", 2)).
+		Sort("group_id").
+		Config
+	config.Fields = model.Fields
+	config.Table = "testmodel"
+	expectedArgs = []interface{}{1, 100, 2}
+	expectedSql = "SELECT `group_id`,sum(`amount`) AS `total` FROM `testmodel` WHERE `id` > ? GROUP BY `group_id` HAVING ( sum(`amount`) > ? AND count(*) >= ? ) ORDER BY `group_id` ASC"
+	queryString, args, err = dialect.BuildSelect(config)
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+	if !slices.Equal(args, expectedArgs) {
+		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
+	}
+
 	// LIMIT and OFFSET
 	config = model.Filter("id", "=", 1).Offset(20).Limit(10).Config
 	config.Fields = model.Fields
@@ -281,6 +475,25 @@ func TestBuildSelect(t *testing.T) {
 	if !slices.Equal(args, expectedArgs) {
 		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
 	}
+
+	// FOR UPDATE
+	config = model.Filter("id", "=", 1).ForUpdate().SkipLocked().Config
+	config.Fields = model.Fields
+	config.Table = "testmodel"
+	_, _, err = dialect.BuildSelect(config)
+	if err == nil || err.Error() != "rem: SELECT does not support row locking" {
+		t.Errorf("Expected row locking error, got '%v'", err)
+	}
+
+	// Count
+	config = model.Query().ForShare().Config
+	config.Count = true
+	config.Fields = model.Fields
+	config.Table = "testmodel"
+	_, _, err = dialect.BuildSelect(config)
+	if err == nil || err.Error() != "rem: SELECT does not support row locking" {
+		t.Errorf("Expected row locking error, got '%v'", err)
+	}
 }
 
 func TestBuildTableColumnAdd(t *testing.T) {
@@ -304,6 +517,13 @@ func TestBuildTableColumnAdd(t *testing.T) {
 	}
 }
 
+func TestBuildTableColumnAlter(t *testing.T) {
+	_, err := SqliteDialect{}.BuildTableColumnAlter(rem.QueryConfig{Table: "testmodel"}, "test_value")
+	if err == nil || err.Error() != "rem: SQLite alters columns by rebuilding the table, which must be executed on a connection. Use TableColumnAlter instead" {
+		t.Errorf("Expected rebuild error, got '%v'", err)
+	}
+}
+
 func TestBuildTableColumnDrop(t *testing.T) {
 	dialect := SqliteDialect{}
 	config := rem.QueryConfig{Table: "testmodel"}
@@ -317,6 +537,19 @@ func TestBuildTableColumnDrop(t *testing.T) {
 	}
 }
 
+func TestBuildTableColumnRename(t *testing.T) {
+	dialect := SqliteDialect{}
+	config := rem.QueryConfig{Table: "testmodel"}
+	expectedSql := "ALTER TABLE `testmodel` RENAME COLUMN `test_value` TO `value`"
+	queryString, err := dialect.BuildTableColumnRename(config, "test_value", "value")
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+}
+
 func TestBuildTableCreate(t *testing.T) {
 	type testModel struct {
 		Id     int64  `db:"test_id" db_primary:"true"`
@@ -354,6 +587,57 @@ func TestBuildTableCreate(t *testing.T) {
 	}
 }
 
+func TestBuildTableCreateComposite(t *testing.T) {
+	type testPairs struct {
+		A int64  `db:"a" db_primary:"true"`
+		B string `db:"b" db_primary:"true" db_max_length:"50"`
+	}
+	type testEvents struct {
+		Id   int64                     `db:"id" db_primary:"true"`
+		Pair rem.ForeignKey[testPairs] `db:"pair_a,pair_b" db_on_delete:"CASCADE"`
+	}
+
+	dialect := SqliteDialect{}
+	pairs := rem.Use[testPairs]()
+	config := rem.QueryConfig{
+		Fields:         pairs.Fields,
+		PrimaryColumns: pairs.PrimaryColumns,
+		Table:          pairs.Table,
+	}
+	expectedSql := "CREATE TABLE `testpairs` (\n" +
+		"\t`a` INTEGER NOT NULL,\n" +
+		"\t`b` TEXT NOT NULL,\n" +
+		"\tPRIMARY KEY (`a`, `b`)\n" +
+		")"
+	queryString, err := dialect.BuildTableCreate(config, rem.TableCreateConfig{})
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+
+	events := rem.Use[testEvents]()
+	config = rem.QueryConfig{
+		Fields:         events.Fields,
+		PrimaryColumns: events.PrimaryColumns,
+		Table:          events.Table,
+	}
+	expectedSql = "CREATE TABLE `testevents` (\n" +
+		"\t`id` INTEGER PRIMARY KEY NOT NULL,\n" +
+		"\t`pair_a` INTEGER NOT NULL,\n" +
+		"\t`pair_b` TEXT NOT NULL,\n" +
+		"\tFOREIGN KEY (`pair_a`, `pair_b`) REFERENCES `testpairs` (`a`, `b`) ON DELETE CASCADE\n" +
+		")"
+	queryString, err = dialect.BuildTableCreate(config, rem.TableCreateConfig{})
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+}
+
 func TestBuildTableDrop(t *testing.T) {
 	dialect := SqliteDialect{}
 	config := rem.QueryConfig{Table: "testmodel"}
@@ -376,6 +660,96 @@ func TestBuildTableDrop(t *testing.T) {
 	}
 }
 
+func TestBuildTableIndexCreate(t *testing.T) {
+	type testModel struct {
+		Email string `db:"email" db_index:"testmodel_email_idx" db_index_unique:"true" db_index_where:"deleted_at IS NULL"`
+		Id    int64  `db:"id" db_primary:"true"`
+		Name  string `db:"name" db_index:"true,testmodel_pair_idx"`
+		Slug  string `db:"slug" db_index:"testmodel_pair_idx"`
+		Tags  string `db:"tags" db_index:"testmodel_tags_idx" db_index_method:"GIN"`
+	}
+
+	dialect := SqliteDialect{}
+	model := rem.Use[testModel]()
+	config := rem.QueryConfig{
+		Indexes: model.Indexes,
+		Table:   model.Table,
+	}
+
+	expectedSql := "CREATE INDEX `testmodel_name_idx` ON `testmodel` (`name`)"
+	queryString, err := dialect.BuildTableIndexCreate(config, "testmodel_name_idx", rem.TableIndexCreateConfig{})
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+
+	expectedSql = "CREATE UNIQUE INDEX IF NOT EXISTS `testmodel_email_idx` ON `testmodel` (`email`) WHERE deleted_at IS NULL"
+	queryString, err = dialect.BuildTableIndexCreate(config, "testmodel_email_idx", rem.TableIndexCreateConfig{IfNotExists: true})
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+
+	expectedSql = "CREATE INDEX `testmodel_pair_idx` ON `testmodel` (`name`, `slug`)"
+	queryString, err = dialect.BuildTableIndexCreate(config, "testmodel_pair_idx", rem.TableIndexCreateConfig{})
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+
+	_, err = dialect.BuildTableIndexCreate(config, "bogus", rem.TableIndexCreateConfig{})
+	if err == nil || err.Error() != "rem: invalid index 'bogus' on model for table 'testmodel'" {
+		t.Errorf("Expected error, got '%v'", err)
+	}
+
+	_, err = dialect.BuildTableIndexCreate(config, "testmodel_tags_idx", rem.TableIndexCreateConfig{})
+	if err == nil || err.Error() != "rem: CREATE INDEX does not support USING" {
+		t.Errorf("Expected error, got '%v'", err)
+	}
+}
+
+func TestBuildTableIndexDrop(t *testing.T) {
+	dialect := SqliteDialect{}
+	config := rem.QueryConfig{Table: "testmodel"}
+
+	expectedSql := "DROP INDEX `testmodel_name_idx`"
+	queryString, err := dialect.BuildTableIndexDrop(config, "testmodel_name_idx", rem.TableIndexDropConfig{})
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+
+	expectedSql = "DROP INDEX IF EXISTS `testmodel_name_idx`"
+	queryString, err = dialect.BuildTableIndexDrop(config, "testmodel_name_idx", rem.TableIndexDropConfig{IfExists: true})
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+}
+
+func TestBuildTableRename(t *testing.T) {
+	dialect := SqliteDialect{}
+	config := rem.QueryConfig{Table: "testmodel"}
+	expectedSql := "ALTER TABLE `testmodel` RENAME TO `renamed`"
+	queryString, err := dialect.BuildTableRename(config, "renamed")
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+}
+
 func TestBuildUpdate(t *testing.T) {
 	type testModel struct {
 		Id     int64  `db:"test_id" db_primary:"true"`
@@ -447,6 +821,87 @@ func TestBuildUpdate(t *testing.T) {
 	if !slices.Equal(args, expectedArgs) {
 		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
 	}
+
+	// RETURNING
+	config = rem.Use[testModel]().Filter("test_id", "=", 1).Config
+	config.Fields = model.Fields
+	config.Table = "testmodel"
+	config.Returning = []string{"test_id"}
+	expectedArgs = []interface{}{"foo", "bar", 1}
+	expectedSql = "UPDATE `testmodel` SET `test_value_1` = ?,`test_value_2` = ? WHERE `test_id` = ? RETURNING `test_id`"
+	queryString, args, err = dialect.BuildUpdate(config, map[string]interface{}{
+		"id":           123,
+		"test_value_1": "foo",
+		"test_value_2": "bar",
+	}, "test_value_1", "test_value_2")
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+	if !slices.Equal(args, expectedArgs) {
+		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
+	}
+}
+
+func TestBuildUpsert(t *testing.T) {
+	type testModel struct {
+		Id     int64  `db:"test_id" db_primary:"true"`
+		Value1 string `db:"test_value_1" db_max_length:"100" db_unique:"true"`
+		Value2 string `db:"test_value_2" db_max_length:"100"`
+	}
+
+	dialect := SqliteDialect{}
+	model := rem.Use[testModel]()
+	rowMap := map[string]interface{}{
+		"test_value_1": "foo",
+		"test_value_2": "bar",
+	}
+
+	config := model.Query().Config
+	config.Fields = model.Fields
+	config.Table = "testmodel"
+	config.Returning = []string{"test_id"}
+	expectedArgs := []interface{}{"foo", "bar"}
+	expectedSql := "INSERT INTO `testmodel` (`test_value_1`,`test_value_2`) VALUES (?,?) ON CONFLICT (`test_value_1`) DO UPDATE SET `test_value_2` = EXCLUDED.`test_value_2` RETURNING `test_id`"
+	queryString, args, err := dialect.BuildUpsert(config, rowMap, rem.UpsertConfig{
+		ConflictColumns: []string{"test_value_1"},
+		UpdateColumns:   []string{"test_value_2"},
+	}, "test_value_1", "test_value_2")
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+	if !slices.Equal(args, expectedArgs) {
+		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
+	}
+
+	// DO NOTHING
+	config.Returning = nil
+	expectedSql = "INSERT INTO `testmodel` (`test_value_1`,`test_value_2`) VALUES (?,?) ON CONFLICT (`test_value_1`) DO NOTHING"
+	queryString, args, err = dialect.BuildUpsert(config, rowMap, rem.UpsertConfig{
+		ConflictColumns: []string{"test_value_1"},
+		DoNothing:       true,
+	}, "test_value_1", "test_value_2")
+	if err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+	if queryString != expectedSql {
+		t.Errorf("Expected '%s', got '%s'", expectedSql, queryString)
+	}
+	if !slices.Equal(args, expectedArgs) {
+		t.Errorf("Expected '%s', got '%s'", expectedArgs, args)
+	}
+
+	_, _, err = dialect.BuildUpsert(config, rowMap, rem.UpsertConfig{
+		ConflictColumns: []string{"test_value_1"},
+	}, "test_value_1", "test_value_2")
+	if err == nil {
+		t.Error("Expected error for missing update columns")
+	}
 }
 
 func TestColumnType(t *testing.T) {
@@ -529,6 +984,192 @@ func TestColumnType(t *testing.T) {
 			t.Fatalf(`dialect.ColumnType() returned '%s', but expected '%s' for '%#v'`, columnType, expected[fieldName], field)
 		}
 	}
+
+	// Composite primary keys are declared as a table constraint.
+	columnType, err := dialect.CompositePrimaryColumnType(model.Fields["test_id"])
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if columnType != "INTEGER NOT NULL" {
+		t.Errorf("Expected 'INTEGER NOT NULL', got '%s'", columnType)
+	}
+}
+
+type testSqliteError struct {
+	code int
+}
+
+func (err testSqliteError) Code() int {
+	return err.code
+}
+
+func (err testSqliteError) Error() string {
+	return fmt.Sprintf("sqlite error %d", err.code)
+}
+
+type testSqliteFieldError struct {
+	Code int
+}
+
+func (err testSqliteFieldError) Error() string {
+	return fmt.Sprintf("sqlite error %d", err.Code)
+}
+
+func TestIntrospectTable(t *testing.T) {
+	dialect := SqliteDialect{}
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM pragma_table_info").
+		WithArgs("accounts").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "type", "notnull", "dflt_value", "pk"}).
+			AddRow("id", "INTEGER", true, nil, 1).
+			AddRow("name", "text", true, "'foo'", 0).
+			AddRow("group_a", "INTEGER", false, nil, 0).
+			AddRow("group_b", "TEXT", false, nil, 0))
+	mock.ExpectQuery("FROM pragma_foreign_key_list").
+		WithArgs("accounts").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "table", "from", "to", "on_update", "on_delete"}).
+			AddRow(0, "groups", "group_a", "a", "NO ACTION", "CASCADE").
+			AddRow(0, "groups", "group_b", "b", "NO ACTION", "CASCADE"))
+	mock.ExpectQuery("FROM pragma_index_list").
+		WithArgs("accounts").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "unique", "name", "sql"}).
+			AddRow("accounts_group_idx", false, "group_a", "CREATE INDEX accounts_group_idx ON accounts (group_a, group_b)").
+			AddRow("accounts_group_idx", false, "group_b", "CREATE INDEX accounts_group_idx ON accounts (group_a, group_b)").
+			AddRow("accounts_name_idx", true, "name", "CREATE UNIQUE INDEX accounts_name_idx ON accounts (name) WHERE group_a IS NULL"))
+	schema, err := dialect.IntrospectTable(context.Background(), db, "accounts")
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	expected := &rem.TableSchema{
+		Columns: []rem.ColumnSchema{
+			{AutoIncrement: true, Name: "id", Type: "INTEGER"},
+			{Default: sql.NullString{String: "'foo'", Valid: true}, Name: "name", Type: "TEXT"},
+			{Name: "group_a", Nullable: true, Type: "INTEGER"},
+			{Name: "group_b", Nullable: true, Type: "TEXT"},
+		},
+		ForeignKeys: []rem.ForeignKeySchema{
+			{Columns: []string{"group_a", "group_b"}, OnDelete: "CASCADE", OnUpdate: "NO ACTION", ReferencedColumns: []string{"a", "b"}, ReferencedTable: "groups"},
+		},
+		Indexes: []rem.Index{
+			{Columns: []string{"group_a", "group_b"}, Name: "accounts_group_idx"},
+			{Columns: []string{"name"}, Name: "accounts_name_idx", Unique: true, Where: "group_a IS NULL"},
+		},
+		Name:       "accounts",
+		PrimaryKey: []string{"id"},
+	}
+	if !reflect.DeepEqual(schema, expected) {
+		t.Errorf("Expected '%+v', got '%+v'", expected, schema)
+	}
+
+	mock.ExpectQuery("pragma_table_info").
+		WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}))
+	if _, err := dialect.IntrospectTable(context.Background(), db, "missing"); !errors.Is(err, rem.ErrTableNotExist) {
+		t.Errorf("Expected missing table error, got '%v'", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	dialect := SqliteDialect{}
+	expected := map[error]bool{
+		testSqliteError{code: 5}:                                   true,
+		testSqliteError{code: 517}:                                 true,
+		fmt.Errorf("wrapped: %w", testSqliteError{code: 5}):        true,
+		errors.New("database is locked"):                           false,
+		testSqliteError{code: 6}:                                   true,
+		testSqliteFieldError{Code: 5}:                              true,
+		fmt.Errorf("wrapped: %w", testSqliteFieldError{Code: 262}): true,
+		testSqliteFieldError{Code: 19}:                             false,
+		testSqliteError{code: 19}:                                  false,
+	}
+	for err, retryable := range expected {
+		if dialect.IsRetryableError(err) != retryable {
+			t.Errorf("Expected '%t' for '%v'", retryable, err)
+		}
+	}
+}
+
+func TestLockMigrations(t *testing.T) {
+	defer func(expiry time.Duration, interval time.Duration) {
+		migrationLockExpiry = expiry
+		migrationLockInterval = interval
+	}(migrationLockExpiry, migrationLockInterval)
+	migrationLockInterval = time.Millisecond
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `migrationlock`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO `migrationlock`").WillReturnError(errors.New("UNIQUE constraint failed: migrationlock.id"))
+	mock.ExpectExec("DELETE FROM `migrationlock` WHERE `id` = 1 AND `locked_at` < datetime\\('now', '-60 seconds'\\)").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO `migrationlock`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("DELETE FROM `migrationlock` WHERE `id` = 1$").WillReturnResult(sqlmock.NewResult(0, 1))
+	unlock, err := SqliteDialect{}.LockMigrations(context.Background(), db, time.Second)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err := unlock(); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `migrationlock`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO `migrationlock`").WillReturnError(errors.New("UNIQUE constraint failed: migrationlock.id"))
+	mock.ExpectExec("DELETE FROM `migrationlock` WHERE `id` = 1 AND `locked_at` <").WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = SqliteDialect{}.LockMigrations(context.Background(), db, time.Nanosecond)
+	if !errors.Is(err, rem.ErrMigrationLockTimeout) {
+		t.Errorf("Expected rem.ErrMigrationLockTimeout, got '%v'", err)
+	}
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `migrationlock`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO `migrationlock`").WillReturnError(errors.New("disk I/O error"))
+	_, err = SqliteDialect{}.LockMigrations(context.Background(), db, time.Second)
+	if err == nil || err.Error() != "disk I/O error" {
+		t.Errorf("Expected 'disk I/O error', got '%v'", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// Expired locks left by crashed processes are taken over without waiting for the timeout, and held locks are refreshed.
+	migrationLockExpiry = 3 * time.Millisecond
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `migrationlock`").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO `migrationlock`").WillReturnError(errors.New("UNIQUE constraint failed: migrationlock.id"))
+	mock.ExpectExec("DELETE FROM `migrationlock` WHERE `id` = 1 AND `locked_at` < datetime\\('now', '-1 seconds'\\)").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `migrationlock`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE `migrationlock` SET `locked_at` = CURRENT_TIMESTAMP WHERE `id` = 1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM `migrationlock` WHERE `id` = 1$").WillReturnResult(sqlmock.NewResult(0, 1))
+	unlock, err = SqliteDialect{}.LockMigrations(context.Background(), db, 0)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if err := unlock(); err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParamLimit(t *testing.T) {
+	if limit := (SqliteDialect{}).ParamLimit(); limit != 999 {
+		t.Errorf("Expected 999, got %d", limit)
+	}
+	if limit := (SqliteDialect{MaxVariableNumber: 32766}).ParamLimit(); limit != 32766 {
+		t.Errorf("Expected 32766, got %d", limit)
+	}
 }
 
 func TestQuoteIdentifier(t *testing.T) {
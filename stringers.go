@@ -5,6 +5,49 @@ import (
 	"strings"
 )
 
+type SqlAggregate struct {
+	Column   interface{}
+	Function string
+}
+
+func (aggregate SqlAggregate) StringForDialect(dialect Dialect) string {
+	switch cv := aggregate.Column.(type) {
+	case string:
+		if cv == "*" {
+			return fmt.Sprint(aggregate.Function, "(*)")
+		}
+		return fmt.Sprint(aggregate.Function, "(", dialect.QuoteIdentifier(cv), ")")
+
+	case DialectStringer:
+		return fmt.Sprint(aggregate.Function, "(", cv.StringForDialect(dialect), ")")
+
+	case fmt.Stringer:
+		return fmt.Sprint(aggregate.Function, "(", cv.String(), ")")
+	}
+
+	panic(fmt.Sprintf("rem: unsupported type for aggregate %s() '%#v'", aggregate.Function, aggregate.Column))
+}
+
+func Avg(column interface{}) SqlAggregate {
+	return SqlAggregate{Column: column, Function: "avg"}
+}
+
+func Count(column interface{}) SqlAggregate {
+	return SqlAggregate{Column: column, Function: "count"}
+}
+
+func Max(column interface{}) SqlAggregate {
+	return SqlAggregate{Column: column, Function: "max"}
+}
+
+func Min(column interface{}) SqlAggregate {
+	return SqlAggregate{Column: column, Function: "min"}
+}
+
+func Sum(column interface{}) SqlAggregate {
+	return SqlAggregate{Column: column, Function: "sum"}
+}
+
 type SqlAs struct {
 	Alias  string
 	Column interface{}
@@ -6,6 +6,29 @@ import (
 	"golang.org/x/exp/slices"
 )
 
+func TestAggregate(t *testing.T) {
+	dialect := testDialect{}
+	expected := map[string]SqlAggregate{
+		`avg("x")`:      Avg("x"),
+		`count(*)`:      Count("*"),
+		`count("x")`:    Count("x"),
+		`max(upper(x))`: Max(Unsafe("upper(x)")),
+		`min("x")`:      Min(Column("x")),
+		`sum("x")`:      Sum("x"),
+	}
+	for expected, aggregate := range expected {
+		sql := aggregate.StringForDialect(dialect)
+		if expected != sql {
+			t.Errorf("Expected '%+v', got '%+v'", expected, sql)
+		}
+	}
+
+	as := As(Sum("x"), "y").StringForDialect(dialect)
+	if as != `sum("x") AS "y"` {
+		t.Errorf(`Expected 'sum("x") AS "y"', got '%s'`, as)
+	}
+}
+
 func TestSql(t *testing.T) {
 	dialect := testDialect{}
 	sql, args, err := Sql(`SELECT count(1) AS "x"`).StringWithArgs(dialect, []interface{}{})
@@ -0,0 +1,132 @@
+package rem
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+type TransactionBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+type TransactionConfig struct {
+	Dialect     Dialect
+	Isolation   sql.IsolationLevel
+	MaxAttempts int
+	ReadOnly    bool
+}
+
+var (
+	retryBaseDelay = 10 * time.Millisecond
+	retryMaxDelay  = time.Second
+)
+
+var savepointCounter atomic.Uint64
+
+func InTransaction(ctx context.Context, db Executor, config *TransactionConfig, fn func(tx *sql.Tx) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if config == nil {
+		config = &TransactionConfig{}
+	}
+
+	if tx, ok := db.(*sql.Tx); ok {
+		return inSavepoint(ctx, tx, fn)
+	}
+
+	beginner, ok := db.(TransactionBeginner)
+	if !ok {
+		return fmt.Errorf("rem: executor of type %T cannot begin transactions", db)
+	}
+
+	dialect := config.Dialect
+	if dialect == nil {
+		dialect = defaultDialect
+	}
+
+	maxAttempts := config.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 3
+	}
+
+	checker, _ := dialect.(RetryableErrorChecker)
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		err = inTransactionAttempt(ctx, beginner, config, fn)
+		if err == nil || checker == nil || !checker.IsRetryableError(err) || attempt == maxAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(retryDelay(attempt)):
+		}
+	}
+	return err
+}
+
+func retryDelay(attempt int) time.Duration {
+	// Exponential backoff with jitter keeps conflicting transactions from retrying in lockstep.
+	delay := retryMaxDelay
+	if attempt < 32 && retryBaseDelay<<(attempt-1) < retryMaxDelay {
+		delay = retryBaseDelay << (attempt - 1)
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func inTransactionAttempt(ctx context.Context, beginner TransactionBeginner, config *TransactionConfig, fn func(tx *sql.Tx) error) (err error) {
+	tx, err := beginner.BeginTx(ctx, &sql.TxOptions{
+		Isolation: config.Isolation,
+		ReadOnly:  config.ReadOnly,
+	})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+func inSavepoint(ctx context.Context, tx *sql.Tx, fn func(tx *sql.Tx) error) error {
+	savepoint := fmt.Sprintf("rem_savepoint_%d", savepointCounter.Add(1))
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint)
+			panic(r)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		if _, rollbackErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+	_, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+savepoint)
+	return err
+}
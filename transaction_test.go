@@ -0,0 +1,160 @@
+package rem
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestInTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal("failed to open sqlmock database:", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	config := &TransactionConfig{Dialect: testDialect{}}
+
+	// Commit.
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE x").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	err = InTransaction(ctx, db, config, func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE x")
+		return err
+	})
+	if err != nil {
+		t.Error("Unexpected error:", err)
+	}
+
+	// Rollback on error.
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	err = InTransaction(ctx, db, config, func(tx *sql.Tx) error {
+		return errors.New("failed")
+	})
+	if err == nil || err.Error() != "failed" {
+		t.Errorf("Expected 'failed', got '%v'", err)
+	}
+
+	// Rollback on panic.
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("Expected panic 'boom', got '%v'", r)
+			}
+		}()
+		InTransaction(ctx, db, config, func(tx *sql.Tx) error {
+			panic("boom")
+		})
+	}()
+
+	// Retry.
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	attempts := 0
+	err = InTransaction(ctx, db, config, func(tx *sql.Tx) error {
+		attempts++
+		if attempts == 1 {
+			return errors.New("retryable")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error("Unexpected error:", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected '2' attempts, got '%d'", attempts)
+	}
+
+	// Retries exhausted.
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	attempts = 0
+	err = InTransaction(ctx, db, &TransactionConfig{Dialect: testDialect{}, MaxAttempts: 2}, func(tx *sql.Tx) error {
+		attempts++
+		return errors.New("retryable")
+	})
+	if err == nil || err.Error() != "retryable" {
+		t.Errorf("Expected 'retryable', got '%v'", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected '2' attempts, got '%d'", attempts)
+	}
+
+	// Canceled while waiting to retry.
+	func(delay time.Duration) {
+		defer func() {
+			retryBaseDelay = delay
+		}()
+		retryBaseDelay = time.Hour
+		ctx, cancel := context.WithCancel(ctx)
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		attempts = 0
+		err := InTransaction(ctx, db, config, func(tx *sql.Tx) error {
+			attempts++
+			cancel()
+			return errors.New("retryable")
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got '%v'", err)
+		}
+		if attempts != 1 {
+			t.Errorf("Expected '1' attempt, got '%d'", attempts)
+		}
+	}(retryBaseDelay)
+
+	// Savepoints.
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT rem_savepoint_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT rem_savepoint_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT rem_savepoint_2").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RELEASE SAVEPOINT rem_savepoint_2").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	savepointCounter.Store(0)
+	err = InTransaction(ctx, db, config, func(tx *sql.Tx) error {
+		err := InTransaction(ctx, tx, config, func(tx *sql.Tx) error {
+			return errors.New("nested")
+		})
+		if err == nil || err.Error() != "nested" {
+			t.Errorf("Expected 'nested', got '%v'", err)
+		}
+		return InTransaction(ctx, tx, config, func(tx *sql.Tx) error {
+			return nil
+		})
+	})
+	if err != nil {
+		t.Error("Unexpected error:", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	for attempt, limit := range map[int]time.Duration{
+		1:  retryBaseDelay,
+		2:  retryBaseDelay * 2,
+		3:  retryBaseDelay * 4,
+		40: retryMaxDelay,
+	} {
+		for i := 0; i < 10; i++ {
+			if delay := retryDelay(attempt); delay < limit/2 || delay > limit {
+				t.Errorf("Expected attempt %d to wait between '%s' and '%s', got '%s'", attempt, limit/2, limit, delay)
+			}
+		}
+	}
+}